@@ -0,0 +1,300 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const tagOptionArray = "array"
+
+var (
+	ErrArrayType  = errors.New("unsupported array type")
+	ErrArrayValue = errors.New("invalid array value")
+)
+
+type (
+	// ArrayValue is the Postgres array argument and scan target returned by Array.
+	ArrayValue interface {
+		driver.Valuer
+		sql.Scanner
+	}
+
+	array struct {
+		value any
+	}
+)
+
+// Array wraps the slice passed as the Postgres array argument or the pointer to the slice
+// scanned from the array column, e.g. db.Array(&tags). Elements can be strings, booleans,
+// numbers, byte slices and pointers to them for NULL elements, struct fields tagged with
+// the `array` option, e.g. `db:"tags,array"`, are wrapped automatically.
+func Array(value any) ArrayValue {
+	return &array{value: value}
+}
+
+// Value encodes the slice as the array literal, e.g. `{"foo","bar"}`.
+func (a *array) Value() (driver.Value, error) {
+	rv := reflect.Indirect(reflect.ValueOf(a.value))
+
+	if !rv.IsValid() || rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil, nil //nolint:nilnil // NULL value
+	}
+
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: %s", ErrArrayType, rv.Type())
+	}
+
+	var buf strings.Builder
+
+	buf.WriteByte('{')
+
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := writeArrayElement(&buf, rv.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return buf.String(), nil
+}
+
+// Scan decodes the one-dimensional array literal into the slice, NULL sets the nil slice.
+func (a *array) Scan(src any) error {
+	rv := reflect.ValueOf(a.value)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %T", ErrArrayType, a.value)
+	}
+
+	rv = rv.Elem()
+
+	var text string
+
+	switch value := src.(type) {
+	case nil:
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	case []byte:
+		text = string(value)
+	case string:
+		text = value
+	default:
+		return fmt.Errorf("%w: %T", ErrColumnType, src)
+	}
+
+	items, err := parseArray(text)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(rv.Type(), len(items), len(items))
+
+	for i, item := range items {
+		if err = setArrayElement(result.Index(i), item); err != nil {
+			return err
+		}
+	}
+
+	rv.Set(result)
+
+	return nil
+}
+
+func writeArrayElement(buf *strings.Builder, rv reflect.Value) error {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			buf.WriteString("NULL")
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	//nolint:exhaustive // other kinds are not supported
+	switch rv.Kind() {
+	case reflect.String:
+		writeArrayString(buf, rv.String())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()))
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrArrayType, rv.Type())
+		}
+
+		writeArrayString(buf, `\x`+hex.EncodeToString(rv.Bytes()))
+	default:
+		return fmt.Errorf("%w: %s", ErrArrayType, rv.Type())
+	}
+
+	return nil
+}
+
+func writeArrayString(buf *strings.Builder, value string) {
+	buf.WriteByte('"')
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' || value[i] == '\\' {
+			buf.WriteByte('\\')
+		}
+
+		buf.WriteByte(value[i])
+	}
+
+	buf.WriteByte('"')
+}
+
+// parseArray splits the array literal into elements, NULL elements are nil.
+func parseArray(text string) ([]*string, error) {
+	// the literal can be decorated with the dimensions, e.g. `[0:1]={1,2}`
+	if strings.HasPrefix(text, "[") {
+		if _, literal, ok := strings.Cut(text, "="); ok {
+			text = literal
+		}
+	}
+
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, fmt.Errorf("%w: %q", ErrArrayValue, text)
+	}
+
+	text = text[1 : len(text)-1]
+	items := make([]*string, 0)
+
+	if text == "" {
+		return items, nil
+	}
+
+	for pos := 0; ; pos++ {
+		var (
+			item   strings.Builder
+			quoted bool
+		)
+
+		switch {
+		case pos < len(text) && text[pos] == '{':
+			return nil, fmt.Errorf("%w: multidimensional arrays are not supported", ErrArrayValue)
+
+		case pos < len(text) && text[pos] == '"':
+			quoted = true
+
+			for pos++; pos < len(text) && text[pos] != '"'; pos++ {
+				if text[pos] == '\\' {
+					pos++
+				}
+
+				if pos < len(text) {
+					item.WriteByte(text[pos])
+				}
+			}
+
+			if pos >= len(text) {
+				return nil, fmt.Errorf("%w: unterminated quoted element", ErrArrayValue)
+			}
+
+			pos++
+
+		default:
+			for ; pos < len(text) && text[pos] != ','; pos++ {
+				item.WriteByte(text[pos])
+			}
+		}
+
+		if value := item.String(); quoted || !strings.EqualFold(value, "NULL") {
+			items = append(items, &value)
+		} else {
+			items = append(items, nil)
+		}
+
+		if pos >= len(text) {
+			return items, nil
+		}
+
+		if text[pos] != ',' {
+			return nil, fmt.Errorf("%w: unexpected %q", ErrArrayValue, text[pos])
+		}
+	}
+}
+
+func setArrayElement(rv reflect.Value, item *string) error {
+	if item == nil {
+		//nolint:exhaustive // only nillable kinds accept NULL
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice:
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		default:
+			return fmt.Errorf("%w: NULL element for %s", ErrArrayValue, rv.Type())
+		}
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		rv = rv.Elem()
+	}
+
+	var err error
+
+	//nolint:exhaustive // other kinds are not supported
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(*item)
+	case reflect.Interface:
+		rv.Set(reflect.ValueOf(*item))
+	case reflect.Bool:
+		var value bool
+		if value, err = strconv.ParseBool(*item); err == nil {
+			rv.SetBool(value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var value int64
+		if value, err = strconv.ParseInt(*item, 10, rv.Type().Bits()); err == nil {
+			rv.SetInt(value)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var value uint64
+		if value, err = strconv.ParseUint(*item, 10, rv.Type().Bits()); err == nil {
+			rv.SetUint(value)
+		}
+	case reflect.Float32, reflect.Float64:
+		var value float64
+		if value, err = strconv.ParseFloat(*item, rv.Type().Bits()); err == nil {
+			rv.SetFloat(value)
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrArrayType, rv.Type())
+		}
+
+		var value []byte
+		if hexValue, ok := strings.CutPrefix(*item, `\x`); ok {
+			value, err = hex.DecodeString(hexValue)
+		} else {
+			value = []byte(*item)
+		}
+
+		rv.SetBytes(value)
+	default:
+		return fmt.Errorf("%w: %s", ErrArrayType, rv.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrArrayValue, err)
+	}
+
+	return nil
+}
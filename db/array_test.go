@@ -0,0 +1,146 @@
+package db_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+func TestArrayValue(t *testing.T) {
+	foo := "foo"
+	tests := []struct {
+		name     string
+		value    any
+		expected any
+		err      error
+	}{
+		{"nil slice", []string(nil), nil, nil},
+		{"empty slice", []int{}, "{}", nil},
+		{"strings", []string{"foo", `b"a\r`, ""}, `{"foo","b\"a\\r",""}`, nil},
+		{"pointers", []*string{&foo, nil}, `{"foo",NULL}`, nil},
+		{"numbers", []float64{1.5, -2}, "{1.5,-2}", nil},
+		{"pointer to slice", &[]int64{1, 2}, "{1,2}", nil},
+		{"booleans", []bool{true, false}, "{true,false}", nil},
+		{"bytes", [][]byte{{0xde, 0xad}}, `{"\\xdead"}`, nil},
+		{"not a slice", 1, nil, db.ErrArrayType},
+		{"unsupported element", []struct{}{{}}, nil, db.ErrArrayType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Helper()
+
+			value, err := db.Array(test.value).Value()
+
+			switch {
+			case !errors.Is(err, test.err):
+				t.Errorf("got error %v, want %v", err, test.err)
+			case value != test.expected:
+				t.Errorf("got %v, want %v", value, test.expected)
+			}
+		})
+	}
+}
+
+func TestArrayScan(t *testing.T) {
+	t.Run("test scan strings", func(t *testing.T) {
+		t.Helper()
+
+		var value []*string
+
+		if err := db.Array(&value).Scan([]byte(`{foo,"b\"a\\r",NULL,"NULL",""}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		actual := make([]any, len(value))
+
+		for i, item := range value {
+			if item != nil {
+				actual[i] = *item
+			}
+		}
+
+		if expected := []any{"foo", `b"a\r`, nil, "NULL", ""}; !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %v, want %v", actual, expected)
+		}
+	})
+
+	t.Run("test scan numbers", func(t *testing.T) {
+		t.Helper()
+
+		var value []int32
+
+		if err := db.Array(&value).Scan("[1:3]={1,-2,3}"); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(value, []int32{1, -2, 3}) {
+			t.Errorf("got %v, want [1 -2 3]", value)
+		}
+	})
+
+	t.Run("test scan booleans and bytes", func(t *testing.T) {
+		t.Helper()
+
+		var (
+			flags []bool
+			data  [][]byte
+		)
+
+		if err := db.Array(&flags).Scan("{t,f}"); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(flags, []bool{true, false}) {
+			t.Errorf("got %v, want [true false]", flags)
+		}
+
+		if err := db.Array(&data).Scan(`{"\\xdead"}`); err != nil {
+			t.Error(err)
+		} else if !reflect.DeepEqual(data, [][]byte{{0xde, 0xad}}) {
+			t.Errorf("got %v, want [[222 173]]", data)
+		}
+	})
+
+	t.Run("test scan empty and null", func(t *testing.T) {
+		t.Helper()
+
+		value := []string{"foo"}
+
+		if err := db.Array(&value).Scan("{}"); err != nil || value == nil || len(value) != 0 {
+			t.Errorf("got %v and %v, want empty slice", value, err)
+		}
+
+		if err := db.Array(&value).Scan(nil); err != nil || value != nil {
+			t.Errorf("got %v and %v, want nil slice", value, err)
+		}
+	})
+
+	t.Run("test scan errors", func(t *testing.T) {
+		t.Helper()
+
+		var (
+			numbers []int
+			text    []string
+		)
+
+		tests := []struct {
+			name   string
+			target any
+			src    any
+			err    error
+		}{
+			{"not a pointer", numbers, "{1}", db.ErrArrayType},
+			{"not an array", &text, "foo", db.ErrArrayValue},
+			{"multidimensional", &numbers, "{{1},{2}}", db.ErrArrayValue},
+			{"unterminated", &text, `{"foo}`, db.ErrArrayValue},
+			{"invalid number", &numbers, "{foo}", db.ErrArrayValue},
+			{"null element", &numbers, "{NULL}", db.ErrArrayValue},
+			{"column type", &numbers, 1, db.ErrColumnType},
+		}
+
+		for _, test := range tests {
+			if err := db.Array(test.target).Scan(test.src); !errors.Is(err, test.err) {
+				t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+			}
+		}
+	})
+}
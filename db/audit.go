@@ -0,0 +1,407 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+const (
+	AuditInsert = "INSERT"
+	AuditUpdate = "UPDATE"
+	AuditDelete = "DELETE"
+	AuditUpsert = "UPSERT"
+
+	defaultAuditTable = "audit_log"
+)
+
+var (
+	ErrAuditUnsupported = errors.New("audit log is not supported by the driver")
+	auditStatementRegex = regexp.MustCompile(`(?is)^\s*(INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+([^\s(]+)`)
+)
+
+type (
+	// AuditEntry is the row of the audit table, see WithAudit.
+	AuditEntry struct {
+		ID        int64        `db:"id,auto"`
+		Actor     *string      `db:"actor"`
+		Table     string       `db:"table_name"`
+		Key       string       `db:"row_key"`
+		Operation string       `db:"operation"`
+		Changes   AuditChanges `db:"changes"`
+		CreatedAt time.Time    `db:"created_at"`
+	}
+
+	// AuditChanges is the before/after values of the changed columns stored as JSON,
+	// values of encrypted columns are redacted.
+	AuditChanges map[string]AuditChange
+
+	AuditChange struct {
+		Old any `json:"old"`
+		New any `json:"new"`
+	}
+
+	actorContextKey struct{}
+
+	auditSkipContextKey struct{}
+)
+
+// WithAudit records every Create, Update, Delete and ForceDelete of the repository into
+// the audit table (audit_log by default, see AuditSchema) in the same transaction as the change,
+// the transaction is started when the repository runs on *DB. The actor is taken from
+// the context, see WithActor.
+func WithAudit(table string) RepositoryOption {
+	return repositoryOptionFunc(func(o *repositoryOptions) {
+		if table == "" {
+			table = defaultAuditTable
+		}
+
+		o.auditTable = table
+	})
+}
+
+// WithAuditLog records the writes of Insert, InsertMany, Upsert, ExecNamed and of every
+// repository into the audit table (audit_log by default, see AuditSchema) in the same
+// transaction as the write, WithAudit of the repository overrides the table. Inserts record
+// the inserted rows, AuditUpsert entries record the written values as the conflicting row
+// may be kept or updated. ExecNamed records INSERT, UPDATE and DELETE statements with the
+// named arguments as new values, the affected rows are not compared.
+func WithAuditLog(table string) Option {
+	return optionFunc(func(o *options) {
+		if table == "" {
+			table = defaultAuditTable
+		}
+
+		o.auditTable = table
+	})
+}
+
+// WithActor returns the context carrying the actor recorded in the audit log, e.g. the user ID.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	return actor, ok && actor != ""
+}
+
+// AuditSchema returns the statement creating the audit table, e.g. to add it to a migration.
+func AuditSchema(drv DriverName, table string) (string, error) {
+	var id, text, timestamp string
+
+	//nolint:exhaustive // only these drivers are supported
+	switch drv {
+	case Postgres, PGX:
+		id, text, timestamp = "BIGSERIAL PRIMARY KEY", "TEXT", "TIMESTAMPTZ"
+	case SQLite:
+		id, text, timestamp = "INTEGER PRIMARY KEY AUTOINCREMENT", "TEXT", "TIMESTAMP"
+	case MySQL:
+		id, text, timestamp = "BIGINT AUTO_INCREMENT PRIMARY KEY", "LONGTEXT", "DATETIME(6)"
+	case SQLServer:
+		id, text, timestamp = "BIGINT IDENTITY PRIMARY KEY", "NVARCHAR(MAX)", "DATETIME2"
+	default:
+		return "", fmt.Errorf("%w: %s", ErrAuditUnsupported, drv)
+	}
+
+	return `CREATE TABLE ` + table + ` (
+	id ` + id + `,
+	actor VARCHAR(255),
+	table_name VARCHAR(255) NOT NULL,
+	row_key VARCHAR(255) NOT NULL,
+	operation VARCHAR(16) NOT NULL,
+	changes ` + text + ` NOT NULL,
+	created_at ` + timestamp + ` NOT NULL
+)`, nil
+}
+
+// Scan decodes the JSON column.
+func (c *AuditChanges) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, c)
+	case string:
+		return json.Unmarshal([]byte(value), c)
+	default:
+		return fmt.Errorf("%w: %T", ErrColumnType, src)
+	}
+}
+
+// Value encodes the changes as JSON.
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(map[string]AuditChange(c))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// auditLog returns the audit table of the writes, writes made while recording
+// the audited change are not audited again.
+func (s settings) auditLog(ctx context.Context) string {
+	if skip, _ := ctx.Value(auditSkipContextKey{}).(bool); skip {
+		return ""
+	}
+
+	return s.auditTable
+}
+
+// audited runs the write and records its entries into the table in the transaction, the
+// transaction is started when q is *DB outside of a transaction. Queries of fn run with
+// the context passed to it are not audited again.
+func audited(ctx context.Context, q Querier, table string, fn func(ctx context.Context, q Querier) ([]*AuditEntry, error)) error {
+	run := func(q Querier) error {
+		ctx := context.WithValue(ctx, auditSkipContextKey{}, true)
+
+		entries, err := fn(ctx, q)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		actor, ok := ActorFromContext(ctx)
+		now := time.Now().UTC()
+
+		for _, entry := range entries {
+			if ok {
+				entry.Actor = &actor
+			}
+
+			entry.CreatedAt = now
+		}
+
+		return InsertMany(ctx, q, table, entries)
+	}
+
+	if database, ok := q.(*DB); ok && database.txFromContext(ctx) == nil {
+		return database.TransactionalTx(ctx, nil, func(tx *Tx) error {
+			return run(tx)
+		})
+	}
+
+	return run(q)
+}
+
+// auditLog returns the audit table of the repository writes, WithAudit overrides WithAuditLog.
+func (r *Repository[T, ID]) auditLog(ctx context.Context) string {
+	if r.auditTable != "" {
+		return r.auditTable
+	}
+
+	return settingsOf(r.q).auditLog(ctx)
+}
+
+// audited runs the change of the repository and records it, the repository passed to fn
+// runs queries in the transaction and does not audit them again.
+func (r *Repository[T, ID]) audited(
+	ctx context.Context,
+	table string,
+	fn func(ctx context.Context, repo *Repository[T, ID]) (*AuditEntry, error),
+) error {
+	return audited(ctx, r.q, table, func(ctx context.Context, q Querier) ([]*AuditEntry, error) {
+		repo := r.WithQuerier(q)
+		repo.auditTable = ""
+
+		entry, err := fn(ctx, repo)
+		if err != nil || entry == nil {
+			return nil, err
+		}
+
+		entry.Table = r.table
+
+		return []*AuditEntry{entry}, nil
+	})
+}
+
+func (r *Repository[T, ID]) auditCreate(ctx context.Context, table string, value *T) error {
+	return r.audited(ctx, table, func(ctx context.Context, repo *Repository[T, ID]) (*AuditEntry, error) {
+		if err := repo.Create(ctx, value); err != nil {
+			return nil, err
+		}
+
+		rv := reflect.ValueOf(value).Elem()
+
+		return newAuditEntry(AuditInsert, r.pk, r.columns, rv, reflect.Value{}, rv), nil
+	})
+}
+
+func (r *Repository[T, ID]) auditUpdate(ctx context.Context, table string, value *T, columns []string) error {
+	return r.audited(ctx, table, func(ctx context.Context, repo *Repository[T, ID]) (*AuditEntry, error) {
+		fields, err := r.updateFields(columns)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.version != nil {
+			fields = append(fields, r.version)
+		}
+
+		rv := reflect.ValueOf(value).Elem()
+
+		before, err := repo.load(ctx, r.pk.arg(valueByIndex(rv, r.pk.index)))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = repo.Update(ctx, value, columns...); err != nil {
+			return nil, err
+		}
+
+		entry := newAuditEntry(AuditUpdate, r.pk, fields, rv, reflect.ValueOf(before).Elem(), rv)
+		if len(entry.Changes) == 0 {
+			return nil, nil //nolint:nilnil // nothing was changed
+		}
+
+		return entry, nil
+	})
+}
+
+// auditDelete records the removed row, the soft deleted row is recorded with its new values.
+func (r *Repository[T, ID]) auditDelete(ctx context.Context, table string, id ID, force bool) error {
+	return r.audited(ctx, table, func(ctx context.Context, repo *Repository[T, ID]) (*AuditEntry, error) {
+		key := argValue(reflect.ValueOf(id))
+
+		before, err := repo.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if force || r.deleted == nil {
+			err = repo.ForceDelete(ctx, id)
+		} else {
+			err = repo.Delete(ctx, id)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		rv := reflect.ValueOf(before).Elem()
+
+		if force || r.deleted == nil {
+			return newAuditEntry(AuditDelete, r.pk, r.columns, rv, rv, reflect.Value{}), nil
+		}
+
+		after, err := repo.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return newAuditEntry(AuditDelete, r.pk, r.columns, rv, rv, reflect.ValueOf(after).Elem()), nil
+	})
+}
+
+// load returns the row by the primary key including soft deleted rows, the row is locked
+// until the end of the transaction where the driver supports it, so the recorded old values
+// are not changed by concurrent writers.
+func (r *Repository[T, ID]) load(ctx context.Context, id any) (*T, error) {
+	var value T
+
+	condition, args, err := r.scoped(ctx, r.pk.name+" = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var query string
+
+	//nolint:exhaustive // other drivers lock the whole database or do not support row locks
+	switch r.q.DriverName() {
+	case Postgres, PGX, MySQL:
+		query = "SELECT " + columnNames(r.columns) + " FROM " + r.table + " WHERE " + condition + " FOR UPDATE"
+	case SQLServer:
+		query = "SELECT " + columnNames(r.columns) + " FROM " + r.table + " WITH (UPDLOCK, ROWLOCK) WHERE " + condition
+	default:
+		query = "SELECT " + columnNames(r.columns) + " FROM " + r.table + " WHERE " + condition
+	}
+
+	if err = r.q.Get(WithEncryptedTable(ctx, r.table), &value, Rebind(r.q.DriverName(), query), args...); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// newAuditEntry compares the fields of the rows, the invalid before or after value
+// is the missing row of the insert or the delete. The key is empty without the primary key.
+func newAuditEntry(operation string, pk *field, fields []*field, row, before, after reflect.Value) *AuditEntry {
+	entry := &AuditEntry{
+		Operation: operation,
+		Changes:   make(AuditChanges),
+	}
+
+	if pk != nil {
+		entry.Key = fmt.Sprint(auditValue(pk, valueByIndex(row, pk.index)))
+	}
+
+	for _, f := range fields {
+		var change AuditChange
+
+		if before.IsValid() {
+			change.Old = auditValue(f, valueByIndex(before, f.index))
+		}
+
+		if after.IsValid() {
+			change.New = auditValue(f, valueByIndex(after, f.index))
+		}
+
+		if before.IsValid() && after.IsValid() && auditEqual(f, before, after) {
+			continue
+		}
+
+		entry.Changes[f.name] = change
+	}
+
+	return entry
+}
+
+// auditValue returns the JSON friendly value of the column, encrypted values are redacted.
+// The field is nil for values of maps.
+func auditValue(f *field, rv reflect.Value) any {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	switch {
+	case !rv.IsValid():
+		return nil
+	case f != nil && f.options.Has(tagOptionEncrypted):
+		return redactedValue
+	}
+
+	value := rv.Interface()
+
+	if t, ok := value.(time.Time); ok {
+		return t.UTC()
+	}
+
+	return value
+}
+
+func auditEqual(f *field, before, after reflect.Value) bool {
+	if f.options.Has(tagOptionEncrypted) {
+		return reflect.DeepEqual(valueByIndex(before, f.index).Interface(), valueByIndex(after, f.index).Interface())
+	}
+
+	oldValue, oldErr := json.Marshal(auditValue(f, valueByIndex(before, f.index)))
+	newValue, newErr := json.Marshal(auditValue(f, valueByIndex(after, f.index)))
+
+	return oldErr == nil && newErr == nil && bytes.Equal(oldValue, newValue)
+}
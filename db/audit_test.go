@@ -0,0 +1,250 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newAuditDB(t *testing.T, opts ...db.Option) *db.DB {
+	t.Helper()
+
+	testDB := newRepositoryDB(t, opts...)
+
+	schema, err := db.AuditSchema(db.SQLite, "audit_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = testDB.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	return testDB
+}
+
+func auditEntries(t *testing.T, testDB *db.DB) []db.AuditEntry {
+	t.Helper()
+
+	var entries []db.AuditEntry
+
+	if err := testDB.Select(context.Background(), &entries, "SELECT * FROM audit_log ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+
+	return entries
+}
+
+func TestAudit(t *testing.T) {
+	testDB := newAuditDB(t)
+	ctx := db.WithActor(context.Background(), "admin")
+
+	users, err := db.NewRepository[repositoryUser, int64](testDB, "users", db.WithAudit(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &repositoryUser{Name: "foo", Status: "active"}
+
+	t.Run("test create", func(t *testing.T) {
+		t.Helper()
+
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		if len(entries) != 1 {
+			t.Fatalf("got %d entries, want 1", len(entries))
+		}
+
+		entry := entries[0]
+
+		if entry.Actor == nil || *entry.Actor != "admin" || entry.Table != "users" || entry.Key != "1" ||
+			entry.Operation != db.AuditInsert || entry.CreatedAt.IsZero() {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+
+		if change := entry.Changes["name"]; change.Old != nil || change.New != "foo" {
+			t.Errorf("got %+v, want the new name", change)
+		}
+	})
+
+	t.Run("test update", func(t *testing.T) {
+		t.Helper()
+
+		user.Name = "bar"
+
+		if err := users.Update(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		entry := entries[len(entries)-1]
+
+		if entry.Operation != db.AuditUpdate || len(entry.Changes) != 2 {
+			t.Fatalf("got %+v, want the update of the name and the version", entry)
+		}
+
+		if change := entry.Changes["name"]; change.Old != "foo" || change.New != "bar" {
+			t.Errorf("got %+v, want foo to bar", change)
+		}
+
+		if change := entry.Changes["version"]; change.Old != float64(1) || change.New != float64(2) {
+			t.Errorf("got %+v, want 1 to 2", change)
+		}
+	})
+
+	t.Run("test failed change", func(t *testing.T) {
+		t.Helper()
+
+		stale := *user
+		stale.Version = 1
+
+		if err := users.Update(ctx, &stale); !errors.Is(err, db.ErrVersionConflict) {
+			t.Fatalf("got %v, want %v", err, db.ErrVersionConflict)
+		}
+
+		errRollback := errors.New("rollback")
+
+		err := testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			if err := users.WithQuerier(tx).Create(ctx, &repositoryUser{Name: "baz", Status: "active"}); err != nil {
+				return err
+			}
+
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("got %v, want %v", err, errRollback)
+		}
+
+		if entries := auditEntries(t, testDB); len(entries) != 2 {
+			t.Errorf("got %d entries, want 2", len(entries))
+		}
+	})
+
+	t.Run("test delete", func(t *testing.T) {
+		t.Helper()
+
+		if err := users.Delete(context.Background(), user.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		entry := entries[len(entries)-1]
+
+		if change, ok := entry.Changes["deleted_at"]; entry.Operation != db.AuditDelete || entry.Actor != nil ||
+			len(entry.Changes) != 1 || !ok || change.Old != nil || change.New == nil {
+			t.Errorf("got %+v, want the soft deleted row", entry)
+		}
+
+		if err := users.ForceDelete(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if entries = auditEntries(t, testDB); len(entries) != 4 {
+			t.Fatalf("got %d entries, want 4", len(entries))
+		}
+
+		if change := entries[3].Changes["name"]; change.Old != "bar" || change.New != nil {
+			t.Errorf("got %+v, want the removed name", change)
+		}
+	})
+
+	t.Run("test unsupported driver", func(t *testing.T) {
+		t.Helper()
+
+		if _, err := db.AuditSchema(db.Clickhouse, "audit_log"); !errors.Is(err, db.ErrAuditUnsupported) {
+			t.Errorf("got %v, want %v", err, db.ErrAuditUnsupported)
+		}
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	testDB := newAuditDB(t, db.WithAuditLog(""))
+	ctx := db.WithActor(context.Background(), "admin")
+
+	t.Run("test insert", func(t *testing.T) {
+		t.Helper()
+
+		user := &repositoryUser{Name: "foo", Status: "active", Version: 1}
+
+		if err := db.Insert(ctx, testDB, "users", user); err != nil {
+			t.Fatal(err)
+		}
+
+		err := db.Upsert(ctx, testDB, "tags", []repositoryTag{{Name: "go", Count: 1}, {Name: "sql", Count: 2}},
+			[]string{"name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		if len(entries) != 3 {
+			t.Fatalf("got %d entries, want 3", len(entries))
+		}
+
+		if entry := entries[0]; entry.Operation != db.AuditInsert || entry.Table != "users" || entry.Key != "1" ||
+			entry.Actor == nil || entry.Changes["name"].New != "foo" {
+			t.Errorf("got %+v, want the inserted user", entry)
+		}
+
+		if entry := entries[2]; entry.Operation != db.AuditUpsert || entry.Table != "tags" || entry.Key != "sql" {
+			t.Errorf("got %+v, want the upserted tag", entry)
+		}
+	})
+
+	t.Run("test exec named", func(t *testing.T) {
+		t.Helper()
+
+		_, err := testDB.ExecNamed(ctx, "UPDATE users SET name = :name WHERE id = :id", map[string]any{"name": "bar", "id": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.ExecNamed(ctx, "UPDATE missing SET name = :name", map[string]any{"name": "baz"}); err == nil {
+			t.Fatal("update of the missing table succeeded")
+		}
+
+		var count int
+
+		if err = testDB.GetNamed(ctx, &count, "SELECT COUNT(*) FROM users WHERE id = :id", map[string]any{"id": 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		if len(entries) != 4 {
+			t.Fatalf("got %d entries, want 4", len(entries))
+		}
+
+		if entry := entries[3]; entry.Operation != db.AuditUpdate || entry.Table != "users" || entry.Key != "1" ||
+			entry.Changes["name"].New != "bar" {
+			t.Errorf("got %+v, want the update of the name", entry)
+		}
+	})
+
+	t.Run("test repository", func(t *testing.T) {
+		t.Helper()
+
+		users, err := db.NewRepository[repositoryUser, int64](testDB, "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = users.Create(ctx, &repositoryUser{Name: "baz", Status: "active"}); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := auditEntries(t, testDB)
+		if len(entries) != 5 {
+			t.Fatalf("got %d entries, want 5", len(entries))
+		}
+
+		if entry := entries[4]; entry.Operation != db.AuditInsert || entry.Key != "2" {
+			t.Errorf("got %+v, want the created user", entry)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package builder
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+var (
+	ErrNoTable  = errors.New("table is not specified")
+	ErrNoValues = errors.New("values are not specified")
+)
+
+type (
+	// Sqlizer is implemented by all statements of the builder.
+	Sqlizer interface {
+		ToSQL() (string, []any, error)
+	}
+
+	// Builder creates statements with the placeholder style of the driver.
+	Builder struct {
+		driver db.DriverName
+	}
+
+	statement struct {
+		driver db.DriverName
+		buf    strings.Builder
+		args   []any
+	}
+)
+
+func New(driver db.DriverName) Builder {
+	return Builder{driver: driver}
+}
+
+func (b Builder) Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{driver: b.driver, columns: columns}
+}
+
+func (b Builder) Insert(table string) *InsertBuilder {
+	return &InsertBuilder{driver: b.driver, table: table}
+}
+
+func (b Builder) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{driver: b.driver, table: table}
+}
+
+func (b Builder) Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{driver: b.driver, table: table}
+}
+
+// Select starts a select statement with question placeholders.
+func Select(columns ...string) *SelectBuilder {
+	return Builder{}.Select(columns...)
+}
+
+// Insert starts an insert statement with question placeholders.
+func Insert(table string) *InsertBuilder {
+	return Builder{}.Insert(table)
+}
+
+// Update starts an update statement with question placeholders.
+func Update(table string) *UpdateBuilder {
+	return Builder{}.Update(table)
+}
+
+// Delete starts a delete statement with question placeholders.
+func Delete(table string) *DeleteBuilder {
+	return Builder{}.Delete(table)
+}
+
+func (s *statement) write(parts ...string) {
+	for _, part := range parts {
+		s.buf.WriteString(part)
+	}
+}
+
+func (s *statement) where(conditions []Condition) {
+	if len(conditions) == 0 {
+		return
+	}
+
+	s.write(" WHERE ")
+	And(conditions...).build(s)
+}
+
+func (s *statement) placeholders(values []any) {
+	for i, value := range values {
+		if i > 0 {
+			s.write(", ")
+		}
+
+		s.value(value)
+	}
+}
+
+func (s *statement) value(value any) {
+	if expr, ok := value.(expression); ok {
+		s.write(expr.sql)
+		s.args = append(s.args, expr.args...)
+
+		return
+	}
+
+	s.write("?")
+	s.args = append(s.args, value)
+}
+
+func (s *statement) result() (string, []any, error) {
+	return db.Rebind(s.driver, s.buf.String()), s.args, nil
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
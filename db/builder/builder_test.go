@@ -0,0 +1,213 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/builder"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestBuilder(t *testing.T) {
+	pg := builder.New(db.Postgres)
+
+	tests := []struct {
+		name  string
+		stmt  builder.Sqlizer
+		query string
+		args  []any
+		err   error
+	}{
+		{
+			"select with conditions",
+			builder.Select("id", "name").From("users").
+				Where(builder.Eq("status", "active"), builder.Or(
+					builder.Like("name", "foo%"),
+					builder.In("id", []int{1, 2}),
+				)).
+				Where(builder.IsNull("deleted_at")).
+				OrderBy("name", "id DESC").
+				Limit(10).
+				Offset(20),
+			"SELECT id, name FROM users WHERE (status = ? AND (name LIKE ? OR id IN (?, ?)) AND deleted_at IS NULL) " +
+				"ORDER BY name, id DESC LIMIT 10 OFFSET 20",
+			[]any{"active", "foo%", 1, 2},
+			nil,
+		},
+		{
+			"select with postgres placeholders",
+			pg.Select().From("users u").
+				LeftJoin("orders o", "o.user_id = u.id AND o.status = ?", "paid").
+				Where(builder.Gte("u.age", 18), builder.Not(builder.Eq("u.role", nil))).
+				GroupBy("u.id").
+				Having(builder.Expr("COUNT(o.id) > ?", 1)),
+			"SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.status = $1 " +
+				"WHERE (u.age >= $2 AND NOT (u.role IS NULL)) GROUP BY u.id HAVING COUNT(o.id) > $3",
+			[]any{"paid", 18, 1},
+			nil,
+		},
+		{
+			"select with raw expressions",
+			builder.Select("id").From("users").
+				Where(builder.Expr("role = ? OR role = ?", "admin", "owner"), builder.Or(builder.Expr("a = 1 AND b = 2"))).
+				Where(builder.IsNull("deleted_at")),
+			"SELECT id FROM users WHERE ((role = ? OR role = ?) AND (a = 1 AND b = 2) AND deleted_at IS NULL)",
+			[]any{"admin", "owner"},
+			nil,
+		},
+		{
+			"select with sql server pagination",
+			builder.New(db.SQLServer).Select("id").From("users").Where(builder.Eq("status", "active")).
+				Limit(10).
+				Offset(20),
+			"SELECT id FROM users WHERE status = @p1 ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+			[]any{"active"},
+			nil,
+		},
+		{
+			"select with sqlite offset",
+			builder.New(db.SQLite).Select("id").From("users").Offset(20),
+			"SELECT id FROM users LIMIT -1 OFFSET 20",
+			nil,
+			nil,
+		},
+		{
+			"select with mysql offset",
+			builder.New(db.MySQL).Select("id").From("users").Offset(20),
+			"SELECT id FROM users LIMIT 18446744073709551615 OFFSET 20",
+			nil,
+			nil,
+		},
+		{
+			"select with empty in",
+			builder.Select("id").From("users").Where(builder.In("id"), builder.NotIn("id", []int{})),
+			"SELECT id FROM users WHERE (1 = 0 AND 1 = 1)",
+			nil,
+			nil,
+		},
+		{
+			"select without table",
+			builder.Select("id"),
+			"",
+			nil,
+			builder.ErrNoTable,
+		},
+		{
+			"insert multiple rows",
+			pg.Insert("users").Columns("id", "name").Values(1, "foo").Values(2, "bar").
+				Suffix("ON CONFLICT (id) DO NOTHING").Returning("id"),
+			"INSERT INTO users (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT (id) DO NOTHING RETURNING id",
+			[]any{1, "foo", 2, "bar"},
+			nil,
+		},
+		{
+			"insert from map",
+			builder.Insert("users").SetMap(map[string]any{"name": "foo", "id": 1}),
+			"INSERT INTO users (id, name) VALUES (?, ?)",
+			[]any{1, "foo"},
+			nil,
+		},
+		{
+			"insert without values",
+			builder.Insert("users"),
+			"",
+			nil,
+			builder.ErrNoValues,
+		},
+		{
+			"update",
+			pg.Update("users").Set("name", "foo").Set("version", builder.Expr("version + ?", 1)).
+				Where(builder.Eq("id", 1), builder.Lt("version", 10)),
+			"UPDATE users SET name = $1, version = version + $2 WHERE (id = $3 AND version < $4)",
+			[]any{"foo", 1, 1, 10},
+			nil,
+		},
+		{
+			"delete",
+			builder.Delete("users").Where(builder.NotEq("id", 1), builder.Gt("age", 10), builder.Lte("age", 20)),
+			"DELETE FROM users WHERE (id <> ? AND age > ? AND age <= ?)",
+			[]any{1, 10, 20},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Helper()
+
+			query, args, err := test.stmt.ToSQL()
+
+			switch {
+			case test.err != nil:
+				if !errors.Is(err, test.err) {
+					t.Fatalf("got error %v, want %v", err, test.err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case query != test.query:
+				t.Fatalf("got query %q, want %q", query, test.query)
+			case !reflect.DeepEqual(args, test.args):
+				t.Fatalf("got args %v, want %v", args, test.args)
+			}
+		})
+	}
+}
+
+func TestBuilderQuerier(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	_, err = builder.Insert("users").Columns("id", "name").Values(1, "foo").Values(2, "bar").Exec(ctx, testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = builder.Update("users").Set("name", "baz").Where(builder.Eq("id", 2)).Exec(ctx, testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	err = builder.Select("name").From("users").Where(builder.In("id", 1, 2)).OrderBy("id").Select(ctx, testDB, &names)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{"foo", "baz"}) {
+		t.Errorf("got %v, want [foo baz]", names)
+	}
+
+	names = nil
+
+	err = builder.Select("name").From("users").OrderBy("id").Offset(1).Select(ctx, testDB, &names)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{"baz"}) {
+		t.Errorf("got %v, want [baz]", names)
+	}
+
+	_, err = builder.Delete("users").Where(builder.Eq("id", 1)).Exec(ctx, testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+
+	if err = builder.Select("COUNT(*)").From("users").Get(ctx, testDB, &count); err != nil {
+		t.Error(err)
+	} else if count != 1 {
+		t.Errorf("got %d, want 1", count)
+	}
+}
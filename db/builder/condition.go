@@ -0,0 +1,233 @@
+package builder
+
+import "reflect"
+
+type (
+	// Condition is a part of the WHERE or HAVING clause.
+	Condition interface {
+		build(s *statement)
+	}
+
+	expression struct {
+		sql  string
+		args []any
+	}
+
+	compare struct {
+		column   string
+		operator string
+		value    any
+	}
+
+	in struct {
+		column string
+		values []any
+		not    bool
+	}
+
+	null struct {
+		column string
+		not    bool
+	}
+
+	group struct {
+		operator   string
+		conditions []Condition
+	}
+
+	not struct {
+		condition Condition
+	}
+)
+
+// Expr is a raw SQL expression with question placeholders, it can be used
+// as a condition or as a value of the insert and update statements.
+func Expr(sql string, args ...any) Condition {
+	return expression{sql: sql, args: args}
+}
+
+// Eq is `column = value`, the nil value is compared with IS NULL.
+func Eq(column string, value any) Condition {
+	if value == nil {
+		return null{column: column}
+	}
+
+	return compare{column, "=", value}
+}
+
+// NotEq is `column <> value`, the nil value is compared with IS NOT NULL.
+func NotEq(column string, value any) Condition {
+	if value == nil {
+		return null{column: column, not: true}
+	}
+
+	return compare{column, "<>", value}
+}
+
+func Gt(column string, value any) Condition {
+	return compare{column, ">", value}
+}
+
+func Gte(column string, value any) Condition {
+	return compare{column, ">=", value}
+}
+
+func Lt(column string, value any) Condition {
+	return compare{column, "<", value}
+}
+
+func Lte(column string, value any) Condition {
+	return compare{column, "<=", value}
+}
+
+func Like(column string, pattern string) Condition {
+	return compare{column, "LIKE", pattern}
+}
+
+func IsNull(column string) Condition {
+	return null{column: column}
+}
+
+func IsNotNull(column string) Condition {
+	return null{column: column, not: true}
+}
+
+// In is `column IN (values)`, a single slice value is expanded into the list.
+func In(column string, values ...any) Condition {
+	return in{column: column, values: expand(values)}
+}
+
+// NotIn is `column NOT IN (values)`, a single slice value is expanded into the list.
+func NotIn(column string, values ...any) Condition {
+	return in{column: column, values: expand(values), not: true}
+}
+
+func And(conditions ...Condition) Condition {
+	return group{"AND", conditions}
+}
+
+func Or(conditions ...Condition) Condition {
+	return group{"OR", conditions}
+}
+
+func Not(condition Condition) Condition {
+	return not{condition}
+}
+
+func (e expression) build(s *statement) {
+	s.write(e.sql)
+	s.args = append(s.args, e.args...)
+}
+
+func (c compare) build(s *statement) {
+	s.write(c.column, " ", c.operator, " ")
+	s.value(c.value)
+}
+
+func (c in) build(s *statement) {
+	// the empty list never matches, so it is replaced with the constant condition
+	if len(c.values) == 0 {
+		if c.not {
+			s.write("1 = 1")
+		} else {
+			s.write("1 = 0")
+		}
+
+		return
+	}
+
+	s.write(c.column)
+
+	if c.not {
+		s.write(" NOT")
+	}
+
+	s.write(" IN (")
+	s.placeholders(c.values)
+	s.write(")")
+}
+
+func (c null) build(s *statement) {
+	if c.not {
+		s.write(c.column, " IS NOT NULL")
+	} else {
+		s.write(c.column, " IS NULL")
+	}
+}
+
+func (c group) build(s *statement) {
+	if len(c.conditions) == 1 {
+		c.conditions[0].build(s)
+		return
+	}
+
+	if len(c.conditions) == 0 {
+		if c.operator == "OR" {
+			s.write("1 = 0")
+		} else {
+			s.write("1 = 1")
+		}
+
+		return
+	}
+
+	s.write("(")
+
+	for i, condition := range c.conditions {
+		if i > 0 {
+			s.write(" ", c.operator, " ")
+		}
+
+		buildNested(s, condition)
+	}
+
+	s.write(")")
+}
+
+// buildNested builds the condition of the group, raw expressions are parenthesized,
+// so their operators never escape the group, other conditions are single terms
+// or parenthesize themselves.
+func buildNested(s *statement, condition Condition) {
+	for {
+		g, ok := condition.(group)
+		if !ok || len(g.conditions) != 1 {
+			break
+		}
+
+		condition = g.conditions[0]
+	}
+
+	if _, ok := condition.(expression); ok {
+		s.write("(")
+		condition.build(s)
+		s.write(")")
+
+		return
+	}
+
+	condition.build(s)
+}
+
+func (c not) build(s *statement) {
+	s.write("NOT (")
+	c.condition.build(s)
+	s.write(")")
+}
+
+func expand(values []any) []any {
+	if len(values) != 1 {
+		return values
+	}
+
+	rv := reflect.ValueOf(values[0])
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return values
+	}
+
+	result := make([]any, rv.Len())
+	for i := range result {
+		result[i] = rv.Index(i).Interface()
+	}
+
+	return result
+}
@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+type DeleteBuilder struct {
+	driver db.DriverName
+	table  string
+	where  []Condition
+}
+
+func (b *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+func (b *DeleteBuilder) ToSQL() (string, []any, error) {
+	return b.build(b.driver)
+}
+
+// Exec runs the statement with the placeholder style of the querier.
+func (b *DeleteBuilder) Exec(ctx context.Context, q db.Querier) (sql.Result, error) {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ExecContext(ctx, query, args...)
+}
+
+func (b *DeleteBuilder) build(driver db.DriverName) (string, []any, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+
+	s := &statement{driver: driver}
+
+	s.write("DELETE FROM ", b.table)
+	s.where(b.where)
+
+	return s.result()
+}
@@ -0,0 +1,119 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+type InsertBuilder struct {
+	driver    db.DriverName
+	table     string
+	columns   []string
+	values    [][]any
+	suffix    []Condition
+	returning []string
+}
+
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Values adds a row of values, it can be called several times to insert multiple rows.
+func (b *InsertBuilder) Values(values ...any) *InsertBuilder {
+	b.values = append(b.values, values)
+	return b
+}
+
+// SetMap adds the columns and a single row of values from the map sorted by column name.
+func (b *InsertBuilder) SetMap(values map[string]any) *InsertBuilder {
+	columns := sortedKeys(values)
+	row := make([]any, len(columns))
+
+	for i, column := range columns {
+		row[i] = values[column]
+	}
+
+	b.columns = columns
+	b.values = [][]any{row}
+
+	return b
+}
+
+// Suffix adds a raw SQL part after the values, e.g. `ON CONFLICT (id) DO NOTHING`.
+func (b *InsertBuilder) Suffix(sql string, args ...any) *InsertBuilder {
+	b.suffix = append(b.suffix, expression{sql: " " + sql, args: args})
+	return b
+}
+
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+func (b *InsertBuilder) ToSQL() (string, []any, error) {
+	return b.build(b.driver)
+}
+
+// Exec runs the statement with the placeholder style of the querier.
+func (b *InsertBuilder) Exec(ctx context.Context, q db.Querier) (sql.Result, error) {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ExecContext(ctx, query, args...)
+}
+
+// Get runs the statement and scans the returning columns into data.
+func (b *InsertBuilder) Get(ctx context.Context, q db.Querier, data any) error {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return err
+	}
+
+	return q.Get(ctx, data, query, args...)
+}
+
+func (b *InsertBuilder) build(driver db.DriverName) (string, []any, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+
+	if len(b.values) == 0 {
+		return "", nil, ErrNoValues
+	}
+
+	s := &statement{driver: driver}
+
+	s.write("INSERT INTO ", b.table)
+
+	if len(b.columns) > 0 {
+		s.write(" (", strings.Join(b.columns, ", "), ")")
+	}
+
+	s.write(" VALUES ")
+
+	for i, row := range b.values {
+		if i > 0 {
+			s.write(", ")
+		}
+
+		s.write("(")
+		s.placeholders(row)
+		s.write(")")
+	}
+
+	for _, suffix := range b.suffix {
+		suffix.build(s)
+	}
+
+	if len(b.returning) > 0 {
+		s.write(" RETURNING ", strings.Join(b.returning, ", "))
+	}
+
+	return s.result()
+}
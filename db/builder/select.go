@@ -0,0 +1,184 @@
+package builder
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+type SelectBuilder struct {
+	driver  db.DriverName
+	columns []string
+	from    string
+	joins   []Condition
+	where   []Condition
+	groupBy []string
+	having  []Condition
+	orderBy []string
+	limit   *uint64
+	offset  *uint64
+}
+
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join adds `JOIN table ON condition`, the condition may contain question placeholders.
+func (b *SelectBuilder) Join(table, on string, args ...any) *SelectBuilder {
+	return b.join("JOIN", table, on, args)
+}
+
+func (b *SelectBuilder) LeftJoin(table, on string, args ...any) *SelectBuilder {
+	return b.join("LEFT JOIN", table, on, args)
+}
+
+// Where adds conditions combined with AND, it can be called several times.
+func (b *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+func (b *SelectBuilder) Having(conditions ...Condition) *SelectBuilder {
+	b.having = append(b.having, conditions...)
+	return b
+}
+
+// OrderBy adds sort expressions such as `name` or `created_at DESC`.
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+func (b *SelectBuilder) Limit(limit uint64) *SelectBuilder {
+	b.limit = &limit
+	return b
+}
+
+func (b *SelectBuilder) Offset(offset uint64) *SelectBuilder {
+	b.offset = &offset
+	return b
+}
+
+func (b *SelectBuilder) ToSQL() (string, []any, error) {
+	return b.build(b.driver)
+}
+
+// Select runs the statement with the placeholder style of the querier and scans the rows into data.
+func (b *SelectBuilder) Select(ctx context.Context, q db.Querier, data any) error {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return err
+	}
+
+	return q.Select(ctx, data, query, args...)
+}
+
+// Get runs the statement with the placeholder style of the querier and scans the first row into data.
+func (b *SelectBuilder) Get(ctx context.Context, q db.Querier, data any) error {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return err
+	}
+
+	return q.Get(ctx, data, query, args...)
+}
+
+func (b *SelectBuilder) join(kind, table, on string, args []any) *SelectBuilder {
+	b.joins = append(b.joins, expression{sql: " " + kind + " " + table + " ON " + on, args: args})
+	return b
+}
+
+func (b *SelectBuilder) build(driver db.DriverName) (string, []any, error) {
+	if b.from == "" {
+		return "", nil, ErrNoTable
+	}
+
+	s := &statement{driver: driver}
+	columns := "*"
+
+	if len(b.columns) > 0 {
+		columns = strings.Join(b.columns, ", ")
+	}
+
+	s.write("SELECT ", columns, " FROM ", b.from)
+
+	for _, join := range b.joins {
+		join.build(s)
+	}
+
+	s.where(b.where)
+
+	if len(b.groupBy) > 0 {
+		s.write(" GROUP BY ", strings.Join(b.groupBy, ", "))
+	}
+
+	if len(b.having) > 0 {
+		s.write(" HAVING ")
+		And(b.having...).build(s)
+	}
+
+	if len(b.orderBy) > 0 {
+		s.write(" ORDER BY ", strings.Join(b.orderBy, ", "))
+	}
+
+	if driver == db.SQLServer {
+		b.writeFetch(s)
+	} else {
+		b.writeLimit(s)
+	}
+
+	return s.result()
+}
+
+func (b *SelectBuilder) writeLimit(s *statement) {
+	switch {
+	case b.limit != nil:
+		s.write(" LIMIT ", strconv.FormatUint(*b.limit, 10))
+	case b.offset != nil && s.driver == db.SQLite:
+		// SQLite and MySQL do not accept OFFSET without LIMIT
+		s.write(" LIMIT -1")
+	case b.offset != nil && s.driver == db.MySQL:
+		s.write(" LIMIT 18446744073709551615")
+	}
+
+	if b.offset != nil {
+		s.write(" OFFSET ", strconv.FormatUint(*b.offset, 10))
+	}
+}
+
+// writeFetch paginates with OFFSET and FETCH of SQL Server, which requires sorted rows,
+// so unsorted rows are sorted by the constant.
+func (b *SelectBuilder) writeFetch(s *statement) {
+	if b.limit == nil && b.offset == nil {
+		return
+	}
+
+	if len(b.orderBy) == 0 {
+		s.write(" ORDER BY (SELECT NULL)")
+	}
+
+	var offset uint64
+
+	if b.offset != nil {
+		offset = *b.offset
+	}
+
+	s.write(" OFFSET ", strconv.FormatUint(offset, 10), " ROWS")
+
+	if b.limit != nil {
+		s.write(" FETCH NEXT ", strconv.FormatUint(*b.limit, 10), " ROWS ONLY")
+	}
+}
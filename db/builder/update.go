@@ -0,0 +1,94 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+type (
+	UpdateBuilder struct {
+		driver    db.DriverName
+		table     string
+		set       []assignment
+		where     []Condition
+		returning []string
+	}
+
+	assignment struct {
+		column string
+		value  any
+	}
+)
+
+// Set adds `column = value`, the value may be an Expr, e.g. Expr("version + 1").
+func (b *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	b.set = append(b.set, assignment{column, value})
+	return b
+}
+
+// SetMap adds assignments from the map sorted by column name.
+func (b *UpdateBuilder) SetMap(values map[string]any) *UpdateBuilder {
+	for _, column := range sortedKeys(values) {
+		b.Set(column, values[column])
+	}
+
+	return b
+}
+
+func (b *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+func (b *UpdateBuilder) ToSQL() (string, []any, error) {
+	return b.build(b.driver)
+}
+
+// Exec runs the statement with the placeholder style of the querier.
+func (b *UpdateBuilder) Exec(ctx context.Context, q db.Querier) (sql.Result, error) {
+	query, args, err := b.build(q.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ExecContext(ctx, query, args...)
+}
+
+func (b *UpdateBuilder) build(driver db.DriverName) (string, []any, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+
+	if len(b.set) == 0 {
+		return "", nil, ErrNoValues
+	}
+
+	s := &statement{driver: driver}
+
+	s.write("UPDATE ", b.table, " SET ")
+
+	for i, item := range b.set {
+		if i > 0 {
+			s.write(", ")
+		}
+
+		s.write(item.column, " = ")
+		s.value(item.value)
+	}
+
+	s.where(b.where)
+
+	if len(b.returning) > 0 {
+		s.write(" RETURNING ", strings.Join(b.returning, ", "))
+	}
+
+	return s.result()
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kamilov/go-kit/utils/structure"
+)
+
+type (
+	// lru is the least recently used cache, it is guarded by the owner.
+	lru[V any] struct {
+		size  int
+		items map[string]*list.Element
+		order *list.List
+		evict func(value V)
+	}
+
+	lruEntry[V any] struct {
+		key   string
+		value V
+	}
+
+	resultCache struct {
+		mutex sync.Mutex
+		items *lru[*cachedResult]
+	}
+
+	cachedResult struct {
+		value   reflect.Value
+		expires time.Time
+		tags    []string
+	}
+
+	cacheParams struct {
+		ttl  time.Duration
+		tags []string
+	}
+
+	cacheContextKey struct{}
+)
+
+// WithCache returns the context caching results of DB.Select and DB.Get for the TTL, the DB
+// must be created with WithResultCache. Results are keyed by the query, the arguments and
+// the destination type and are dropped by DB.InvalidateCache with any of the tags. Queries
+// inside transactions are never cached. Cached values are shallow copies, so pointers, maps
+// and slices inside of them are shared and must not be modified.
+func WithCache(ctx context.Context, ttl time.Duration, tags ...string) context.Context {
+	return context.WithValue(ctx, cacheContextKey{}, cacheParams{ttl: ttl, tags: tags})
+}
+
+// InvalidateCache drops cached results having any of the tags or all results without tags.
+func (db *DB) InvalidateCache(tags ...string) {
+	if db.results == nil {
+		return
+	}
+
+	db.results.mutex.Lock()
+	defer db.results.mutex.Unlock()
+
+	if len(tags) == 0 {
+		db.results.items.clear()
+		return
+	}
+
+	db.results.items.removeFunc(func(item *cachedResult) bool {
+		return slices.ContainsFunc(item.tags, func(tag string) bool { return slices.Contains(tags, tag) })
+	})
+}
+
+// cached returns the cached result into data or runs the query and caches its result.
+func (db *DB) cached(ctx context.Context, data any, query string, args []any, run func() error) error {
+	params, ok := ctx.Value(cacheContextKey{}).(cacheParams)
+	if !ok || db.results == nil || params.ttl <= 0 || db.txFromContext(ctx) != nil {
+		return run()
+	}
+
+	if structure.ValidatePointer(data) != nil {
+		return run()
+	}
+
+	key, err := cacheKey(data, query, args)
+	if err != nil {
+		return run()
+	}
+
+	dst := reflect.ValueOf(data).Elem()
+
+	// rows are appended to the slice like Select does, so only the appended rows are cached
+	if dst.Kind() == reflect.Slice {
+		if value, ok := db.results.get(key); ok {
+			dst.Set(reflect.AppendSlice(dst, value))
+			return nil
+		}
+
+		start := dst.Len()
+
+		if err = run(); err != nil {
+			return err
+		}
+
+		db.results.add(key, newCachedResult(dst.Slice(start, dst.Len()), params))
+
+		return nil
+	}
+
+	if value, ok := db.results.get(key); ok {
+		dst.Set(copyResult(value))
+		return nil
+	}
+
+	if err = run(); err != nil {
+		return err
+	}
+
+	db.results.add(key, newCachedResult(dst, params))
+
+	return nil
+}
+
+func newCachedResult(rv reflect.Value, params cacheParams) *cachedResult {
+	return &cachedResult{value: copyResult(rv), expires: time.Now().Add(params.ttl), tags: params.tags}
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{items: newLRU[*cachedResult](size, nil)}
+}
+
+func (c *resultCache) get(key string) (reflect.Value, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.items.get(key)
+
+	switch {
+	case !ok:
+		return reflect.Value{}, false
+	case time.Now().After(item.expires):
+		c.items.remove(key)
+		return reflect.Value{}, false
+	default:
+		return item.value, true
+	}
+}
+
+func (c *resultCache) add(key string, item *cachedResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items.add(key, item)
+}
+
+// cacheKey hashes the query, the arguments and the type of the destination.
+func cacheKey(data any, query string, args []any) (string, error) {
+	h := sha256.New()
+
+	_, _ = fmt.Fprintf(h, "%T\x00%s", data, query)
+
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+
+			arg = value
+		}
+
+		rv := reflect.ValueOf(arg)
+
+		for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+
+		if rv.IsValid() {
+			arg = rv.Interface()
+		}
+
+		if t, ok := arg.(time.Time); ok {
+			arg = t.UTC().Format(time.RFC3339Nano)
+		}
+
+		_, _ = fmt.Fprintf(h, "\x00%T:%v", arg, arg)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyResult copies the value, the elements of slices and maps are copied as well.
+func copyResult(rv reflect.Value) reflect.Value {
+	//nolint:exhaustive // other kinds are copied by value
+	switch rv.Kind() {
+	case reflect.Slice:
+		result := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(result, rv)
+
+		return result
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+
+		result := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+
+		for iter := rv.MapRange(); iter.Next(); {
+			result.SetMapIndex(iter.Key(), iter.Value())
+		}
+
+		return result
+	default:
+		result := reflect.New(rv.Type()).Elem()
+		result.Set(rv)
+
+		return result
+	}
+}
+
+func newLRU[V any](size int, evict func(value V)) *lru[V] {
+	return &lru[V]{size: size, items: make(map[string]*list.Element), order: list.New(), evict: evict}
+}
+
+func (c *lru[V]) get(key string) (V, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return elem.Value.(*lruEntry[V]).value, true
+}
+
+// add stores the value replacing the previous one and evicts the least recently used values.
+func (c *lru[V]) add(key string, value V) {
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		c.drop(elem)
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+
+	for c.order.Len() > c.size {
+		elem := c.order.Back()
+		c.order.Remove(elem)
+		delete(c.items, elem.Value.(*lruEntry[V]).key)
+		c.drop(elem)
+	}
+}
+
+func (c *lru[V]) remove(key string) {
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		c.drop(elem)
+	}
+}
+
+func (c *lru[V]) removeFunc(fn func(value V) bool) {
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+
+		if entry := elem.Value.(*lruEntry[V]); fn(entry.value) {
+			c.remove(entry.key)
+		}
+
+		elem = next
+	}
+}
+
+func (c *lru[V]) clear() {
+	c.removeFunc(func(V) bool { return true })
+}
+
+func (c *lru[V]) drop(elem *list.Element) {
+	if c.evict != nil {
+		c.evict(elem.Value.(*lruEntry[V]).value)
+	}
+}
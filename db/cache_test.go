@@ -0,0 +1,139 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestResultCache(t *testing.T) {
+	testDB, err := db.New(db.WithConfigDSN("sqlite://:memory:"), db.WithResultCache(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		_ = testDB.Close()
+	})
+
+	_, err = testDB.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL); INSERT INTO items VALUES (1, 'foo')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rename := func(t *testing.T, name string) {
+		t.Helper()
+
+		if _, err := testDB.Exec("UPDATE items SET name = ? WHERE id = 1", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(t *testing.T, ctx context.Context) string {
+		t.Helper()
+
+		var name string
+
+		if err := testDB.Get(ctx, &name, "SELECT name FROM items WHERE id = ?", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		return name
+	}
+
+	t.Run("test cached", func(t *testing.T) {
+		t.Helper()
+
+		ctx := db.WithCache(context.Background(), time.Minute, "items")
+
+		if got := get(t, ctx); got != "foo" {
+			t.Fatalf("got %q, want foo", got)
+		}
+
+		rename(t, "bar")
+
+		if got := get(t, ctx); got != "foo" {
+			t.Errorf("got %q, want the cached foo", got)
+		}
+
+		if got := get(t, context.Background()); got != "bar" {
+			t.Errorf("got %q, want bar without the cache", got)
+		}
+
+		testDB.InvalidateCache("users")
+
+		if got := get(t, ctx); got != "foo" {
+			t.Errorf("got %q, want the cached foo", got)
+		}
+
+		testDB.InvalidateCache("items")
+
+		if got := get(t, ctx); got != "bar" {
+			t.Errorf("got %q, want bar after the invalidation", got)
+		}
+	})
+
+	t.Run("test ttl", func(t *testing.T) {
+		t.Helper()
+
+		ctx := db.WithCache(context.Background(), 20*time.Millisecond)
+
+		var before, after []string
+
+		if err := testDB.Select(ctx, &before, "SELECT name FROM items"); err != nil {
+			t.Fatal(err)
+		}
+
+		before[0] = "modified"
+
+		rename(t, "baz")
+
+		after = nil
+
+		if err := testDB.Select(ctx, &after, "SELECT name FROM items"); err != nil || after[0] != "bar" {
+			t.Fatalf("got %v and %v, want the cached bar", after, err)
+		}
+
+		time.Sleep(30 * time.Millisecond)
+
+		after = nil
+
+		if err := testDB.Select(ctx, &after, "SELECT name FROM items"); err != nil || after[0] != "baz" {
+			t.Errorf("got %v and %v, want baz after the TTL", after, err)
+		}
+	})
+
+	t.Run("test transaction", func(t *testing.T) {
+		t.Helper()
+
+		ctx := db.WithCache(context.Background(), time.Minute)
+
+		_ = get(t, ctx)
+
+		err := testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			if _, err := tx.ExecContext(tx.Context(), "UPDATE items SET name = 'qux' WHERE id = 1"); err != nil {
+				return err
+			}
+
+			if got := get(t, tx.Context()); got != "qux" {
+				t.Errorf("got %q, want qux inside the transaction", got)
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testDB.InvalidateCache()
+
+		if got := get(t, ctx); got != "qux" {
+			t.Errorf("got %q, want qux", got)
+		}
+	})
+}
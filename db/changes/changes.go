@@ -0,0 +1,129 @@
+// Package changes delivers row change events of database tables into the bus: triggers
+// send Postgres notifications received by the Listener or write SQLite changes into
+// the table read by the Poller, events are published to the topic of the table:
+//
+//	_ = b.Subscribe(changes.Topic("users"), func(event changes.Event) {
+//		if event.Op != changes.Insert {
+//			cache.Delete(event.OldKey["id"])
+//		}
+//	})
+package changes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kamilov/go-kit/bus"
+	"github.com/kamilov/go-kit/db"
+)
+
+const (
+	Insert Op = "INSERT"
+	Update Op = "UPDATE"
+	Delete Op = "DELETE"
+)
+
+var (
+	ErrUnsupportedDriver = errors.New("change notifications are not supported by the driver")
+	ErrNoKeys            = errors.New("key columns are required")
+	ErrInvalidPayload    = errors.New("invalid change payload")
+)
+
+type (
+	// Op is the operation which changed the row.
+	Op string
+
+	// Key is the key columns of the changed row, numbers are decoded as json.Number.
+	Key map[string]any
+
+	// Event is the row change, OldKey is empty for inserts and NewKey is empty for deletes.
+	Event struct {
+		Table  string `json:"table"`
+		Op     Op     `json:"op"`
+		OldKey Key    `json:"old_key,omitempty"`
+		NewKey Key    `json:"new_key,omitempty"`
+	}
+)
+
+// Topic returns the bus topic of the table changes with the default prefix.
+func Topic(table string) string {
+	return DefaultTopicPrefix + table
+}
+
+// Install creates the triggers tracking changes of the table by the key columns,
+// see Triggers.
+func Install(ctx context.Context, q db.Querier, table string, keys []string, opts ...Option) error {
+	statements, err := Triggers(q.DriverName(), table, keys, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err = q.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Triggers returns the statements creating the triggers, e.g. to add them to a migration.
+// Postgres triggers send notifications to the channel, SQLite triggers insert changes
+// into the changes table created when it does not exist.
+func Triggers(drv db.DriverName, table string, keys []string, opts ...Option) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	o := newOptions(opts)
+
+	//nolint:exhaustive // only these drivers support triggers
+	switch drv {
+	case db.Postgres, db.PGX:
+		return postgresTriggers(table, keys, o), nil
+	case db.SQLite:
+		return sqliteTriggers(table, keys, o), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, drv)
+	}
+}
+
+func publish(b bus.Bus, prefix string, event Event) {
+	b.Publish(prefix+event.Table, event)
+}
+
+// jsonObject builds the JSON object of the key columns of the row, e.g. `NEW`.
+func jsonObject(function, row string, keys []string) string {
+	args := make([]string, len(keys))
+
+	for i, key := range keys {
+		args[i] = quote(key) + ", " + row + "." + key
+	}
+
+	return function + "(" + strings.Join(args, ", ") + ")"
+}
+
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func decodeKey(data []byte) (Key, error) {
+	if len(data) == 0 {
+		return nil, nil //nolint:nilnil // the key is absent for the operation
+	}
+
+	var key Key
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&key); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	return key, nil
+}
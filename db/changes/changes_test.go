@@ -0,0 +1,201 @@
+package changes_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/bus"
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/changes"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func subscribe(t *testing.T, b bus.Bus, topic string) <-chan changes.Event {
+	t.Helper()
+
+	events := make(chan changes.Event, 10)
+
+	if err := b.Subscribe(topic, func(event changes.Event) { events <- event }); err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+func receive(t *testing.T, events <-chan changes.Event) changes.Event {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("event was not published")
+	}
+
+	return changes.Event{}
+}
+
+func TestPoller(t *testing.T) {
+	testDB, err := db.New(db.WithConfigDSN("sqlite://:memory:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+
+	if _, err = testDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = changes.Install(ctx, testDB, "users", []string{"id"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := bus.New(10)
+	events := subscribe(t, b, changes.Topic("users"))
+	poller := changes.NewPoller(testDB, b, changes.WithBatchSize(2))
+
+	queries := []string{
+		"INSERT INTO users (id, name) VALUES (1, 'foo')",
+		"UPDATE users SET id = 2 WHERE id = 1",
+		"DELETE FROM users WHERE id = 2",
+	}
+
+	for _, query := range queries {
+		if _, err = testDB.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("test poll", func(t *testing.T) {
+		t.Helper()
+
+		if count, err := poller.Poll(ctx); err != nil || count != 2 {
+			t.Fatalf("got %d changes and %v, want 2", count, err)
+		}
+
+		expected := []changes.Event{
+			{Table: "users", Op: changes.Insert, NewKey: changes.Key{"id": json.Number("1")}},
+			{Table: "users", Op: changes.Update, OldKey: changes.Key{"id": json.Number("1")}, NewKey: changes.Key{"id": json.Number("2")}},
+		}
+
+		for _, want := range expected {
+			if event := receive(t, events); !reflect.DeepEqual(event, want) {
+				t.Errorf("got %+v, want %+v", event, want)
+			}
+		}
+	})
+
+	t.Run("test run", func(t *testing.T) {
+		t.Helper()
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+
+		go func() {
+			done <- poller.Run(runCtx)
+		}()
+
+		want := changes.Event{Table: "users", Op: changes.Delete, OldKey: changes.Key{"id": json.Number("2")}}
+
+		if event := receive(t, events); !reflect.DeepEqual(event, want) {
+			t.Errorf("got %+v, want %+v", event, want)
+		}
+
+		cancel()
+
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+
+		var count int
+
+		if err := testDB.Get(ctx, &count, "SELECT COUNT(*) FROM row_changes"); err != nil || count != 0 {
+			t.Errorf("got %d changes and %v, want the empty table", count, err)
+		}
+	})
+
+	t.Run("test invalid batch size", func(t *testing.T) {
+		t.Helper()
+
+		if _, err := testDB.Exec("INSERT INTO users (id, name) VALUES (3, 'bar')"); err != nil {
+			t.Fatal(err)
+		}
+
+		poller := changes.NewPoller(testDB, b, changes.WithBatchSize(0), changes.WithInterval(-time.Second))
+
+		if count, err := poller.Poll(ctx); err != nil || count != 1 {
+			t.Fatalf("got %d changes and %v, want 1", count, err)
+		}
+
+		receive(t, events)
+	})
+}
+
+func TestListener(t *testing.T) {
+	payloads := []string{
+		`{"table":"users","op":"INSERT","old_key":null,"new_key":{"id":1}}`,
+		`{"table":"users","op":"DELETE","old_key":{"id":1},"new_key":null}`,
+		`invalid`,
+	}
+
+	b := bus.New(10)
+	events := subscribe(t, b, "app.users")
+	receiver := changes.ReceiverFunc(func(context.Context) (string, error) {
+		payload := payloads[0]
+		payloads = payloads[1:]
+
+		return payload, nil
+	})
+
+	err := changes.NewListener(receiver, b, changes.WithTopicPrefix("app.")).Run(context.Background())
+	if !errors.Is(err, changes.ErrInvalidPayload) {
+		t.Errorf("got %v, want %v", err, changes.ErrInvalidPayload)
+	}
+
+	expected := []changes.Event{
+		{Table: "users", Op: changes.Insert, NewKey: changes.Key{"id": json.Number("1")}},
+		{Table: "users", Op: changes.Delete, OldKey: changes.Key{"id": json.Number("1")}},
+	}
+
+	for _, want := range expected {
+		if event := receive(t, events); !reflect.DeepEqual(event, want) {
+			t.Errorf("got %+v, want %+v", event, want)
+		}
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	statements, err := changes.Triggers(db.PGX, "public.users", []string{"id", "tenant_id"}, changes.WithChannel("events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := strings.Join(statements, ";\n")
+
+	for _, part := range []string{
+		"CREATE OR REPLACE FUNCTION events_public_users()",
+		"pg_notify('events'",
+		"json_build_object('id', OLD.id, 'tenant_id', OLD.tenant_id)",
+		"CREATE TRIGGER events_public_users AFTER INSERT OR UPDATE OR DELETE ON public.users",
+	} {
+		if !strings.Contains(query, part) {
+			t.Errorf("%q does not contain %q", query, part)
+		}
+	}
+
+	if _, err = changes.Triggers(db.MySQL, "users", []string{"id"}); !errors.Is(err, changes.ErrUnsupportedDriver) {
+		t.Errorf("got %v, want %v", err, changes.ErrUnsupportedDriver)
+	}
+
+	if _, err = changes.Triggers(db.SQLite, "users", nil); !errors.Is(err, changes.ErrNoKeys) {
+		t.Errorf("got %v, want %v", err, changes.ErrNoKeys)
+	}
+}
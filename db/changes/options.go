@@ -0,0 +1,89 @@
+package changes
+
+import "time"
+
+const (
+	DefaultTopicPrefix = "db.changes."
+
+	defaultName      = "row_changes"
+	defaultInterval  = time.Second
+	defaultBatchSize = 100
+)
+
+type (
+	options struct {
+		table     string
+		channel   string
+		prefix    string
+		interval  time.Duration
+		batchSize int
+	}
+
+	optionFunc func(*options)
+
+	Option interface {
+		apply(*options)
+	}
+)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		table:     defaultName,
+		channel:   defaultName,
+		prefix:    DefaultTopicPrefix,
+		interval:  defaultInterval,
+		batchSize: defaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+// WithTable sets the name of the SQLite table the triggers write changes to.
+func WithTable(table string) Option {
+	return optionFunc(func(o *options) {
+		o.table = table
+	})
+}
+
+// WithChannel sets the name of the Postgres notification channel.
+func WithChannel(channel string) Option {
+	return optionFunc(func(o *options) {
+		o.channel = channel
+	})
+}
+
+// WithTopicPrefix sets the prefix of the bus topics, events are published to the prefix
+// followed by the table name.
+func WithTopicPrefix(prefix string) Option {
+	return optionFunc(func(o *options) {
+		o.prefix = prefix
+	})
+}
+
+// WithInterval sets the interval of polling the changes table, the non-positive interval
+// keeps the default.
+func WithInterval(interval time.Duration) Option {
+	return optionFunc(func(o *options) {
+		if interval > 0 {
+			o.interval = interval
+		}
+	})
+}
+
+// WithBatchSize limits the number of changes read by a single poll, the non-positive size
+// keeps the default.
+func WithBatchSize(size int) Option {
+	return optionFunc(func(o *options) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	})
+}
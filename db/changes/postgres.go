@@ -0,0 +1,126 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kamilov/go-kit/bus"
+)
+
+type (
+	// Receiver waits for the payload of the next notification on the connection listening
+	// to the channel, e.g. with pgx:
+	//
+	//	_, _ = conn.Exec(ctx, "LISTEN row_changes")
+	//
+	//	receiver := changes.ReceiverFunc(func(ctx context.Context) (string, error) {
+	//		notification, err := conn.WaitForNotification(ctx)
+	//		if err != nil {
+	//			return "", err
+	//		}
+	//
+	//		return notification.Payload, nil
+	//	})
+	Receiver interface {
+		Receive(ctx context.Context) (string, error)
+	}
+
+	ReceiverFunc func(ctx context.Context) (string, error)
+
+	// Listener publishes events of the Postgres notifications sent by the triggers.
+	Listener struct {
+		receiver Receiver
+		bus      bus.Bus
+		prefix   string
+	}
+
+	notification struct {
+		Table  string          `json:"table"`
+		Op     Op              `json:"op"`
+		OldKey json.RawMessage `json:"old_key"`
+		NewKey json.RawMessage `json:"new_key"`
+	}
+)
+
+func (f ReceiverFunc) Receive(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func NewListener(receiver Receiver, b bus.Bus, opts ...Option) *Listener {
+	return &Listener{receiver: receiver, bus: b, prefix: newOptions(opts).prefix}
+}
+
+// Run publishes events until the context is canceled or the receiver fails.
+func (l *Listener) Run(ctx context.Context) error {
+	for {
+		payload, err := l.receiver.Receive(ctx)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		event, err := parseNotification(payload)
+		if err != nil {
+			return err
+		}
+
+		publish(l.bus, l.prefix, event)
+	}
+}
+
+func parseNotification(payload string) (Event, error) {
+	var n notification
+
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return Event{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	event := Event{Table: n.Table, Op: n.Op}
+
+	var err error
+
+	if event.OldKey, err = decodeKey(nullable(n.OldKey)); err != nil {
+		return Event{}, err
+	}
+
+	if event.NewKey, err = decodeKey(nullable(n.NewKey)); err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+func nullable(data json.RawMessage) []byte {
+	if string(data) == "null" {
+		return nil
+	}
+
+	return data
+}
+
+func postgresTriggers(table string, keys []string, o *options) []string {
+	name := o.channel + "_" + strings.ReplaceAll(table, ".", "_")
+
+	return []string{
+		`CREATE OR REPLACE FUNCTION ` + name + `() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify(` + quote(o.channel) + `, json_build_object(
+		'table', ` + quote(table) + `,
+		'op', TG_OP,
+		'old_key', CASE WHEN TG_OP <> 'INSERT' THEN ` + jsonObject("json_build_object", "OLD", keys) + ` END,
+		'new_key', CASE WHEN TG_OP <> 'DELETE' THEN ` + jsonObject("json_build_object", "NEW", keys) + ` END
+	)::text);
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS ` + name + ` ON ` + table,
+		`CREATE TRIGGER ` + name + ` AFTER INSERT OR UPDATE OR DELETE ON ` + table +
+			` FOR EACH ROW EXECUTE FUNCTION ` + name + `()`,
+	}
+}
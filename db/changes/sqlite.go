@@ -0,0 +1,158 @@
+package changes
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kamilov/go-kit/bus"
+	"github.com/kamilov/go-kit/db"
+)
+
+type (
+	// Poller publishes events of the changes written into the changes table by the SQLite
+	// triggers, published changes are removed from the table. Changes are removed after
+	// they are published, so the delivery is at least once: the changes published before
+	// the failed removal are published again by the next poll.
+	Poller struct {
+		q         db.Querier
+		bus       bus.Bus
+		table     string
+		prefix    string
+		interval  time.Duration
+		batchSize int
+	}
+
+	change struct {
+		ID     int64   `db:"id"`
+		Table  string  `db:"table_name"`
+		Op     Op      `db:"op"`
+		OldKey *string `db:"old_key"`
+		NewKey *string `db:"new_key"`
+	}
+)
+
+func NewPoller(q db.Querier, b bus.Bus, opts ...Option) *Poller {
+	o := newOptions(opts)
+
+	return &Poller{
+		q:         q,
+		bus:       b,
+		table:     o.table,
+		prefix:    o.prefix,
+		interval:  o.interval,
+		batchSize: o.batchSize,
+	}
+}
+
+// Run polls the changes table until the context is canceled or the poll fails.
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		// the full batch means more changes are waiting
+		for {
+			count, err := p.Poll(ctx)
+
+			switch {
+			case ctx.Err() != nil:
+				return nil
+			case err != nil:
+				return err
+			}
+
+			if count < p.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll publishes the next batch of changes and returns the number of published changes.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	var changes []change
+
+	query := "SELECT id, table_name, op, old_key, new_key FROM " + p.table + " ORDER BY id LIMIT ?"
+
+	if err := p.q.Select(ctx, &changes, query, p.batchSize); err != nil {
+		return 0, err
+	}
+
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	events := make([]Event, len(changes))
+
+	for i, item := range changes {
+		event, err := item.event()
+		if err != nil {
+			return 0, err
+		}
+
+		events[i] = event
+	}
+
+	for _, event := range events {
+		publish(p.bus, p.prefix, event)
+	}
+
+	last := changes[len(changes)-1].ID
+
+	if _, err := p.q.ExecContext(ctx, "DELETE FROM "+p.table+" WHERE id <= ?", last); err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+func (c change) event() (Event, error) {
+	event := Event{Table: c.Table, Op: c.Op}
+
+	var err error
+
+	if c.OldKey != nil {
+		if event.OldKey, err = decodeKey([]byte(*c.OldKey)); err != nil {
+			return Event{}, err
+		}
+	}
+
+	if c.NewKey != nil {
+		if event.NewKey, err = decodeKey([]byte(*c.NewKey)); err != nil {
+			return Event{}, err
+		}
+	}
+
+	return event, nil
+}
+
+func sqliteTriggers(table string, keys []string, o *options) []string {
+	name := o.table + "_" + strings.ReplaceAll(table, ".", "_")
+	insert := "INSERT INTO " + o.table + " (table_name, op, old_key, new_key) VALUES (" + quote(table) + ", "
+
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + o.table + ` (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	table_name TEXT NOT NULL,
+	op TEXT NOT NULL,
+	old_key TEXT,
+	new_key TEXT
+)`,
+		`CREATE TRIGGER IF NOT EXISTS ` + name + `_insert AFTER INSERT ON ` + table + ` BEGIN
+	` + insert + `'INSERT', NULL, ` + jsonObject("json_object", "NEW", keys) + `);
+END`,
+		`CREATE TRIGGER IF NOT EXISTS ` + name + `_update AFTER UPDATE ON ` + table + ` BEGIN
+	` + insert + `'UPDATE', ` + jsonObject("json_object", "OLD", keys) + `, ` + jsonObject("json_object", "NEW", keys) + `);
+END`,
+		`CREATE TRIGGER IF NOT EXISTS ` + name + `_delete AFTER DELETE ON ` + table + ` BEGIN
+	` + insert + `'DELETE', ` + jsonObject("json_object", "OLD", keys) + `, NULL);
+END`,
+	}
+}
@@ -2,29 +2,87 @@ package db
 
 import (
 	"bytes"
+	"database/sql"
+	"maps"
 	"net/url"
 	"sort"
+	"strings"
+	"time"
+
+	"github.com/kamilov/go-kit/utils/structure"
 )
 
-type driverName string
+type (
+	DriverName string
+	Role       string
+
+	prefixedData struct {
+		data   structure.Data
+		prefix string
+	}
+)
 
 const (
-	PGX        driverName = "pgx"
-	Postgres   driverName = "postgres"
-	Clickhouse driverName = "clickhouse"
-	SQLite     driverName = "sqlite3"
+	PGX        DriverName = "pgx"
+	Postgres   DriverName = "postgres"
+	Clickhouse DriverName = "clickhouse"
+	SQLite     DriverName = "sqlite3"
+	MySQL      DriverName = "mysql"
+	SQLServer  DriverName = "sqlserver"
+
+	RolePrimary Role = "primary"
+	RoleReplica Role = "replica"
 )
 
+// Config is the config of the connection pool, the `env` tags can be decoded with the prefix
+// of the pool, see DecodeConfig, so the primary and every replica are configured separately.
 type Config struct {
-	Hostname string
-	Username string
-	Password string
-	Database string
-	Driver   driverName
+	Hostname string     `env:"DB_HOSTNAME"`
+	Username string     `env:"DB_USERNAME"`
+	Password string     `env:"DB_PASSWORD"`
+	Database string     `env:"DB_DATABASE"`
+	Driver   DriverName `env:"DB_DRIVER"`
 	Params   map[string]string
+	// Role is the role of the connection, the empty role means the primary.
+	Role Role `env:"DB_ROLE"`
+
+	// Pool settings of the connection, zero values keep the database/sql defaults.
+	MaxOpenConns int `env:"DB_MAX_OPEN_CONNS"`
+	// MaxIdleConns is the number of idle connections, the negative value keeps no idle connections.
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+// DecodeConfig decodes the config from the data, e.g. the environment, with keys prefixed
+// by the prefix: the `REPLICA_` prefix decodes REPLICA_DB_HOSTNAME, REPLICA_DB_MAX_OPEN_CONNS
+// and so on. The empty prefix decodes the keys as is.
+func DecodeConfig(data structure.Data, prefix string) (*Config, error) {
+	config := new(Config)
+
+	if err := structure.NewDecoder("env").Decode(prefixedData{data: data, prefix: prefix}, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (d prefixedData) Get(key string) string {
+	return d.data.Get(d.prefix + key)
 }
 
+// DSN returns the connection string parsed back by ParseDSN, PGX uses the pgx scheme
+// to keep the driver.
 func (c *Config) DSN() string {
+	if c.Driver == PGX {
+		return "pgx" + strings.TrimPrefix(c.postgresConnectionString(), "postgres")
+	}
+
+	return c.connectionString()
+}
+
+// connectionString returns the connection string accepted by the driver.
+func (c *Config) connectionString() string {
 	switch c.Driver {
 	case PGX, Postgres:
 		return c.postgresConnectionString()
@@ -32,11 +90,34 @@ func (c *Config) DSN() string {
 		return c.clickhouseConnectionString()
 	case SQLite:
 		return c.sqliteConnectionString()
+	case MySQL:
+		return c.mysqlConnectionString()
+	case SQLServer:
+		return c.sqlServerConnectionString()
 	default:
 		panic("unknown Driver name")
 	}
 }
 
+// applyPool sets the pool settings of the config to the connection.
+func (c *Config) applyPool(sqlDB *sql.DB) {
+	if c.MaxOpenConns != 0 {
+		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	}
+
+	if c.MaxIdleConns != 0 {
+		sqlDB.SetMaxIdleConns(max(c.MaxIdleConns, 0))
+	}
+
+	if c.ConnMaxLifetime != 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+
+	if c.ConnMaxIdleTime != 0 {
+		sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
 func (c *Config) driverName() string {
 	return string(c.Driver)
 }
@@ -59,7 +140,7 @@ func (c *Config) postgresConnectionString() string {
 }
 
 func (c *Config) clickhouseConnectionString() string {
-	params := c.Params
+	params := maps.Clone(c.Params)
 
 	if params == nil {
 		params = map[string]string{}
@@ -90,6 +171,67 @@ func (c *Config) sqliteConnectionString() string {
 	return c.Database + "?" + encodeParams(c.Params)
 }
 
+// mysqlConnectionString returns the DSN in the go-sql-driver format, the hostname
+// starting with a slash is used as the unix socket path.
+func (c *Config) mysqlConnectionString() string {
+	var buf strings.Builder
+
+	if c.Username != "" || c.Password != "" {
+		buf.WriteString(c.Username)
+
+		if c.Password != "" {
+			buf.WriteByte(':')
+			buf.WriteString(c.Password)
+		}
+
+		buf.WriteByte('@')
+	}
+
+	if strings.HasPrefix(c.Hostname, "/") {
+		buf.WriteString("unix(")
+	} else {
+		buf.WriteString("tcp(")
+	}
+
+	buf.WriteString(c.Hostname)
+	buf.WriteString(")/")
+	buf.WriteString(c.Database)
+
+	if len(c.Params) > 0 {
+		buf.WriteByte('?')
+		buf.WriteString(encodeParams(c.Params))
+	}
+
+	return buf.String()
+}
+
+func (c *Config) sqlServerConnectionString() string {
+	params := maps.Clone(c.Params)
+
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	if c.Database != "" {
+		params["database"] = c.Database
+	}
+
+	var dsn url.URL
+
+	dsn.Scheme = "sqlserver"
+
+	if c.Password != "" {
+		dsn.User = url.UserPassword(c.Username, c.Password)
+	} else if c.Username != "" {
+		dsn.User = url.User(c.Username)
+	}
+
+	dsn.Host = c.Hostname
+	dsn.RawQuery = encodeParams(params)
+
+	return dsn.String()
+}
+
 func encodeParams(params map[string]string) string {
 	if len(params) == 0 {
 		return ""
@@ -112,9 +254,9 @@ func encodeParams(params map[string]string) string {
 			_ = buf.WriteByte('&')
 		}
 
-		_, _ = buf.WriteString(key)
+		_, _ = buf.WriteString(url.QueryEscape(key))
 		_ = buf.WriteByte('=')
-		_, _ = buf.WriteString(value)
+		_, _ = buf.WriteString(url.QueryEscape(value))
 	}
 
 	return buf.String()
@@ -30,7 +30,7 @@ func TestConfig(t *testing.T) {
 			Params:   map[string]string{"sslmode": "disable", "charset": "utf8"},
 		}
 
-		if cfg.DSN() != "postgres://postgres@localhost/postgres?charset=utf8&sslmode=disable" {
+		if cfg.DSN() != "pgx://postgres@localhost/postgres?charset=utf8&sslmode=disable" {
 			t.Errorf("invalid PGX DSN: %v", cfg.DSN())
 		}
 	})
@@ -74,6 +74,39 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("test mysql config", func(t *testing.T) {
+		t.Helper()
+
+		cfg := &db.Config{
+			Hostname: "localhost:3306",
+			Username: "mysql",
+			Password: "mysql",
+			Database: "mysql",
+			Driver:   db.MySQL,
+			Params:   map[string]string{"parseTime": "true"},
+		}
+
+		if cfg.DSN() != "mysql:mysql@tcp(localhost:3306)/mysql?parseTime=true" {
+			t.Errorf("invalid MySQL DSN: %v", cfg.DSN())
+		}
+	})
+
+	t.Run("test sql server config", func(t *testing.T) {
+		t.Helper()
+
+		cfg := &db.Config{
+			Hostname: "localhost:1433",
+			Username: "sa",
+			Password: "sa",
+			Database: "master",
+			Driver:   db.SQLServer,
+		}
+
+		if cfg.DSN() != "sqlserver://sa:sa@localhost:1433?database=master" {
+			t.Errorf("invalid SQL Server DSN: %v", cfg.DSN())
+		}
+	})
+
 	t.Run("test unknown driver", func(t *testing.T) {
 		t.Helper()
 
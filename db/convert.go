@@ -0,0 +1,252 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/kamilov/go-kit/coder"
+)
+
+const (
+	tagOptionJSON = "json"
+
+	jsonCoder = "json"
+)
+
+var (
+	ErrNoCoder    = errors.New("coder is not registered, import github.com/kamilov/go-kit/coder/json")
+	ErrColumnType = errors.New("unsupported column value type")
+)
+
+type (
+	converter struct {
+		scan  func(src any, dst reflect.Value) error
+		value func(rv reflect.Value) (driver.Value, error)
+	}
+
+	converterScanner struct {
+		converter *converter
+		dst       reflect.Value
+		pointer   bool
+	}
+
+	converterValue struct {
+		converter *converter
+		rv        reflect.Value
+	}
+
+	jsonScanner struct {
+		ctx context.Context //nolint:containedctx // passed to the decoder of the column
+		dst reflect.Value
+	}
+
+	jsonValue struct {
+		value any
+	}
+)
+
+//nolint:gochecknoglobals // used to register column converters
+var converters sync.Map
+
+// RegisterConverter registers the conversion of column values into T and of T into query
+// arguments, it is used for struct fields, pointers to T, scan targets and named arguments.
+// Either function can be nil to keep the default behavior of database/sql for that direction.
+func RegisterConverter[T any](scan func(src any) (T, error), value func(T) (driver.Value, error)) {
+	c := &converter{}
+
+	if scan != nil {
+		c.scan = func(src any, dst reflect.Value) error {
+			result, err := scan(src)
+			if err != nil {
+				return err
+			}
+
+			dst.Set(reflect.ValueOf(&result).Elem())
+
+			return nil
+		}
+	}
+
+	if value != nil {
+		c.value = func(rv reflect.Value) (driver.Value, error) {
+			v, _ := rv.Interface().(T)
+			return value(v)
+		}
+	}
+
+	converters.Store(reflect.TypeFor[T](), c)
+}
+
+func getConverter(rt reflect.Type) (*converter, bool) {
+	if c, ok := converters.Load(rt); ok {
+		return c.(*converter), false
+	}
+
+	if rt.Kind() == reflect.Pointer {
+		if c, ok := converters.Load(rt.Elem()); ok {
+			return c.(*converter), true
+		}
+	}
+
+	return nil, false
+}
+
+func hasConverter(rt reflect.Type) bool {
+	c, _ := getConverter(rt)
+	return c != nil
+}
+
+// scanTarget returns the destination of rows.Scan for the field, encrypted fields are
+// decrypted with the keyring of the settings.
+func (f *field) scanTarget(ctx context.Context, s settings, rv reflect.Value) any {
+	switch {
+	case f.options.Has(tagOptionEncrypted):
+		return &encryptedScanner{dst: rv, keyring: s.keyring, table: encryptedTable(ctx), column: f.name}
+	case f.options.Has(tagOptionJSON):
+		return &jsonScanner{ctx: ctx, dst: rv}
+	case f.options.Has(tagOptionArray):
+		return Array(rv.Addr().Interface())
+	default:
+		return scanTarget(rv)
+	}
+}
+
+// arg returns the query argument of the field value, the value is invalid for nil embedded structs.
+func (f *field) arg(rv reflect.Value) any {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	switch {
+	case f.options.Has(tagOptionEncrypted):
+		return encryptedValue{rv: rv, deterministic: f.options.Has(tagOptionDeterministic), column: f.name}
+	case f.options.Has(tagOptionJSON):
+		return jsonValue{value: rv.Interface()}
+	case f.options.Has(tagOptionArray):
+		return Array(rv.Interface())
+	default:
+		return argValue(rv)
+	}
+}
+
+func scanTarget(rv reflect.Value) any {
+	if c, pointer := getConverter(rv.Type()); c != nil && c.scan != nil {
+		return &converterScanner{converter: c, dst: rv, pointer: pointer}
+	}
+
+	return rv.Addr().Interface()
+}
+
+func argValue(rv reflect.Value) any {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	c, pointer := getConverter(rv.Type())
+	if c == nil || c.value == nil {
+		return rv.Interface()
+	}
+
+	if pointer {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	return converterValue{converter: c, rv: rv}
+}
+
+func (s *converterScanner) Scan(src any) error {
+	dst := s.dst
+
+	if s.pointer {
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+
+	return s.converter.scan(src, dst)
+}
+
+func (v converterValue) Value() (driver.Value, error) {
+	return v.converter.value(v.rv)
+}
+
+// Scan decodes the column with the coder, NULL resets the field to the zero value.
+func (s *jsonScanner) Scan(src any) error {
+	var data []byte
+
+	switch value := src.(type) {
+	case nil:
+		s.dst.Set(reflect.Zero(s.dst.Type()))
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("%w: %T", ErrColumnType, src)
+	}
+
+	decode := coder.GetDecoder(jsonCoder)
+	if decode == nil {
+		return ErrNoCoder
+	}
+
+	result := reflect.New(s.dst.Type())
+
+	if err := decode(s.ctx, bytes.NewReader(data), result.Interface()); err != nil {
+		return err
+	}
+
+	s.dst.Set(result.Elem())
+
+	return nil
+}
+
+// Value encodes the value with the coder, nil pointers, maps and slices are stored as NULL.
+func (v jsonValue) Value() (driver.Value, error) {
+	rv := reflect.ValueOf(v.value)
+
+	//nolint:exhaustive // only nillable kinds are stored as NULL
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil //nolint:nilnil // NULL value
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil //nolint:nilnil // NULL value
+		}
+	}
+
+	encode := coder.GetEncoder(jsonCoder)
+	if encode == nil {
+		return nil, ErrNoCoder
+	}
+
+	var buf bytes.Buffer
+
+	if err := encode(context.Background(), &buf, v.value); err != nil {
+		return nil, err
+	}
+
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
@@ -0,0 +1,194 @@
+package db_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/kamilov/go-kit/coder/json"
+	"github.com/kamilov/go-kit/db"
+)
+
+type (
+	convertPoint struct {
+		X, Y int
+	}
+
+	convertPayload struct {
+		Name  string   `json:"name"`
+		Items []string `json:"items"`
+	}
+
+	convertRow struct {
+		ID       int64            `db:"id,auto"`
+		Point    convertPoint     `db:"point"`
+		Origin   *convertPoint    `db:"origin"`
+		Payload  convertPayload   `db:"payload,json"`
+		Meta     map[string]int64 `db:"meta,json"`
+		Tags     []string         `db:"tags,array"`
+		Position convertPoint     `db:"position,json"`
+	}
+)
+
+//nolint:gochecknoinits // the converter is registered once for all tests of the package
+func init() {
+	db.RegisterConverter(
+		func(src any) (convertPoint, error) {
+			var point convertPoint
+
+			text, ok := src.(string)
+			if !ok {
+				return point, fmt.Errorf("unexpected point %T", src)
+			}
+
+			_, err := fmt.Sscanf(text, "%d,%d", &point.X, &point.Y)
+
+			return point, err
+		},
+		func(point convertPoint) (driver.Value, error) {
+			return fmt.Sprintf("%d,%d", point.X, point.Y), nil
+		},
+	)
+}
+
+func TestConvert(t *testing.T) {
+	testDB, err := db.New(db.WithConfigDSN("sqlite://:memory:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err = testDB.Exec(`CREATE TABLE items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		point TEXT NOT NULL,
+		origin TEXT,
+		payload TEXT,
+		meta TEXT,
+		tags TEXT,
+		position TEXT
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	expected := convertRow{
+		Point:    convertPoint{X: 1, Y: 2},
+		Payload:  convertPayload{Name: "foo", Items: []string{"a", "b"}},
+		Meta:     map[string]int64{"views": 3},
+		Tags:     []string{"foo", `b"a\r`, "NULL"},
+		Position: convertPoint{X: 3, Y: 4},
+	}
+
+	if err = db.Insert(ctx, testDB, "items", &expected); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test stored values", func(t *testing.T) {
+		t.Helper()
+
+		var row map[string]any
+
+		if err = testDB.Get(ctx, &row, "SELECT * FROM items WHERE id = ?", expected.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]any{
+			"id":       expected.ID,
+			"point":    "1,2",
+			"origin":   nil,
+			"payload":  `{"name":"foo","items":["a","b"]}`,
+			"meta":     `{"views":3}`,
+			"tags":     `{"foo","b\"a\\r","NULL"}`,
+			"position": `{"X":3,"Y":4}`,
+		}
+
+		if !reflect.DeepEqual(row, want) {
+			t.Errorf("got %v, want %v", row, want)
+		}
+	})
+
+	t.Run("test scan struct", func(t *testing.T) {
+		t.Helper()
+
+		var row convertRow
+
+		if err = testDB.Get(ctx, &row, "SELECT * FROM items WHERE id = ?", expected.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(row, expected) {
+			t.Errorf("got %+v, want %+v", row, expected)
+		}
+	})
+
+	t.Run("test scan null", func(t *testing.T) {
+		t.Helper()
+
+		_, err = testDB.Exec("UPDATE items SET origin = '5,6', payload = NULL, meta = NULL, tags = NULL")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var row convertRow
+
+		if err = testDB.Get(ctx, &row, "SELECT * FROM items WHERE id = ?", expected.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		switch {
+		case row.Origin == nil || *row.Origin != (convertPoint{X: 5, Y: 6}):
+			t.Errorf("got origin %v, want 5,6", row.Origin)
+		case row.Meta != nil || row.Tags != nil || row.Payload.Name != "":
+			t.Errorf("got %+v, want zero values", row)
+		}
+	})
+
+	t.Run("test scalar and named arguments", func(t *testing.T) {
+		t.Helper()
+
+		var points []convertPoint
+
+		err = testDB.SelectNamed(ctx, &points, "SELECT point FROM items WHERE point = :point", map[string]any{
+			"point": convertPoint{X: 1, Y: 2},
+		})
+
+		if err != nil {
+			t.Error(err)
+		} else if len(points) != 1 || points[0] != expected.Point {
+			t.Errorf("got %v, want [%v]", points, expected.Point)
+		}
+	})
+
+	t.Run("test invalid json", func(t *testing.T) {
+		t.Helper()
+
+		_, err = testDB.Exec("UPDATE items SET payload = '{\"unknown\":1}'")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var row convertRow
+
+		err = testDB.Get(ctx, &row, "SELECT * FROM items WHERE id = ?", expected.ID)
+		if err == nil || !strings.Contains(err.Error(), "unknown") {
+			t.Errorf("got %v, want unknown field error", err)
+		}
+	})
+
+	t.Run("test invalid json column type", func(t *testing.T) {
+		t.Helper()
+
+		var row convertRow
+
+		err = testDB.Get(ctx, &row, "SELECT 1 AS payload")
+		if !errors.Is(err, db.ErrColumnType) {
+			t.Errorf("got %v, want %v", err, db.ErrColumnType)
+		}
+	})
+}
@@ -6,33 +6,122 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/loghole/dbhook"
 )
 
 type DB struct {
 	*sql.DB
+	settings
+	replicas      *replicaSet
+	healthTimeout time.Duration
+	stmts         map[*sql.DB]*stmtCache
+	results       *resultCache
+	// lockTables are the lease tables created by the locks of the DB
+	lockTables sync.Map
 }
 
 const driverNameRandomSize = 5
 
-var ErrUndefinedConfig = errors.New("configuration is not specified")
+var (
+	ErrUndefinedConfig = errors.New("configuration is not specified")
+	ErrMultiplePrimary = errors.New("only one primary configuration can be specified")
+)
 
 func New(opts ...Option) (*DB, error) {
 	o := &options{
-		hookOptions: make([]dbhook.HookOption, 0),
+		hookOptions:         make([]dbhook.HookOption, 0),
+		balancer:            RoundRobin,
+		healthCheckInterval: defaultHealthCheckInterval,
+		healthCheckTimeout:  defaultHealthCheckTimeout,
 	}
 
 	for _, opt := range opts {
 		opt.apply(o)
 	}
 
-	if o.config == nil {
+	if err := errors.Join(o.errs...); err != nil {
+		return nil, err
+	}
+
+	var (
+		primary  *Config
+		replicas []*Config
+	)
+
+	for _, config := range o.configs {
+		switch {
+		case config.Role == RoleReplica:
+			replicas = append(replicas, config)
+		case primary != nil:
+			return nil, ErrMultiplePrimary
+		default:
+			primary = config
+		}
+	}
+
+	if primary == nil {
 		return nil, ErrUndefinedConfig
 	}
 
 	hooks := dbhook.NewHooks(o.hookOptions...)
-	sqlDB, err := sql.Open(o.config.driverName(), "")
+
+	sqlDB, err := open(primary, hooks)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		DB: sqlDB,
+		settings: settings{
+			driver:       primary.Driver,
+			strict:       o.strict,
+			rowsObserver: o.rowsObserver,
+			keyring:      o.keyring,
+			auditTable:   o.auditTable,
+		},
+		healthTimeout: o.healthCheckTimeout,
+	}
+
+	pools := []*sql.DB{sqlDB}
+
+	if len(replicas) > 0 {
+		replicaDBs := make([]*sql.DB, 0, len(replicas))
+
+		for _, config := range replicas {
+			replicaDB, err := open(config, hooks)
+			if err != nil {
+				for _, item := range replicaDBs {
+					_ = item.Close()
+				}
+
+				_ = sqlDB.Close()
+
+				return nil, err
+			}
+
+			replicaDBs = append(replicaDBs, replicaDB)
+		}
+
+		db.replicas = newReplicaSet(replicaDBs, o.balancer, o.healthCheckInterval, o.healthCheckTimeout)
+		pools = append(pools, replicaDBs...)
+	}
+
+	if o.statementCacheSize > 0 {
+		db.stmts = newStmtCaches(o.statementCacheSize, pools...)
+	}
+
+	if o.resultCacheSize > 0 {
+		db.results = newResultCache(o.resultCacheSize)
+	}
+
+	return db, nil
+}
+
+func open(config *Config, hooks *dbhook.Hooks) (*sql.DB, error) {
+	sqlDB, err := sql.Open(config.driverName(), "")
 	if err != nil {
 		return nil, fmt.Errorf("can't find original driver: %w", err)
 	}
@@ -41,16 +130,33 @@ func New(opts ...Option) (*DB, error) {
 	if _, err = rand.Read(randomBytes); err != nil {
 		return nil, fmt.Errorf("can't read random bytes: %w", err)
 	}
-	replacedDriverName := fmt.Sprintf("%s-with-hooks-%s", o.config.driverName(), randomBytes)
+	replacedDriverName := fmt.Sprintf("%s-with-hooks-%s", config.driverName(), randomBytes)
 
 	sql.Register(replacedDriverName, dbhook.Wrap(sqlDB.Driver(), hooks))
 
-	sqlDB, err = sql.Open(replacedDriverName, o.config.DSN())
+	sqlDB, err = sql.Open(replacedDriverName, config.connectionString())
 	if err != nil {
 		return nil, fmt.Errorf("can't open sql db: %w", err)
 	}
 
-	return &DB{sqlDB}, nil
+	config.applyPool(sqlDB)
+
+	return sqlDB, nil
+}
+
+// Close stops the replica health checks and closes the primary and replica connections.
+func (db *DB) Close() error {
+	var errs []error
+
+	db.ResetStatements()
+
+	if db.replicas != nil {
+		errs = append(errs, db.replicas.close())
+	}
+
+	errs = append(errs, db.DB.Close())
+
+	return errors.Join(errs...)
 }
 
 func (db *DB) Begin() (*Tx, error) {
@@ -59,7 +165,7 @@ func (db *DB) Begin() (*Tx, error) {
 		return nil, err
 	}
 
-	return &Tx{tx}, nil
+	return newTx(context.Background(), db, tx), nil
 }
 
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
@@ -68,7 +174,7 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 		return nil, err
 	}
 
-	return &Tx{tx}, nil
+	return newTx(ctx, db, tx), nil
 }
 
 func (db *DB) Transactional(fn func(tx *Tx) error) (err error) {
@@ -99,12 +205,47 @@ func (db *DB) Transactional(fn func(tx *Tx) error) (err error) {
 	return err
 }
 
-func (db *DB) TransactionalTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+// TransactionalTx runs fn in a transaction. When ctx already carries a transaction of the db
+// (see Tx.Context), the outer transaction is reused and fn runs inside a savepoint, so opts
+// and txOpts can not be used then and fail with ErrNestedTxOptions.
+// With WithRetry the whole transaction is re-run on retryable errors such as
+// serialization failures and deadlocks, the current attempt is available via Tx.Attempt.
+func (db *DB) TransactionalTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error, txOpts ...TxOption) error {
+	if tx := db.txFromContext(ctx); tx != nil {
+		if opts != nil || len(txOpts) > 0 {
+			return ErrNestedTxOptions
+		}
+
+		return tx.savepoint(ctx, fn)
+	}
+
+	o := newTxOptions(txOpts)
+
+	for attempt := 1; ; attempt++ {
+		err := db.transactionalTx(ctx, opts, attempt, o, fn)
+		if err == nil || attempt >= o.attempts || !o.retryable(db.driver, err) {
+			return err
+		}
+
+		timer := time.NewTimer(o.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (db *DB) transactionalTx(ctx context.Context, opts *sql.TxOptions, attempt int, o *txOptions, fn func(tx *Tx) error) (err error) {
 	tx, err := db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
+	tx.attempt = attempt
+
 	defer func() {
 		//nolint:nestif // no check
 		if p := recover(); p != nil {
@@ -122,7 +263,79 @@ func (db *DB) TransactionalTx(ctx context.Context, opts *sql.TxOptions, fn func(
 		}
 	}()
 
+	if o.tenantSchema != nil {
+		if err = tx.setSearchPath(ctx, o.tenantSchema); err != nil {
+			return err
+		}
+	}
+
 	err = fn(tx)
 
 	return err
 }
+
+// Exec runs ExecContext with the background context, so the query uses the replica routing,
+// the statement cache and the encryption like the other queries of the DB.
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// Query runs QueryContext with the background context.
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow runs QueryRowContext with the background context.
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return tx.ExecContext(ctx, query, args...)
+	}
+
+	result, err := db.execContext(ctx, db.DB, query, db.bindEncrypted(ctx, args)...)
+	if err == nil {
+		db.observeResult(ctx, query, result)
+
+		if db.stmts != nil && isSchemaQuery(query) {
+			db.ResetStatements()
+		}
+	}
+
+	return result, err
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return tx.QueryContext(ctx, query, args...)
+	}
+
+	return db.queryContext(ctx, db.reader(ctx, query), query, db.bindEncrypted(ctx, args)...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+
+	return db.queryRowContext(ctx, db.reader(ctx, query), query, db.bindEncrypted(ctx, args)...)
+}
+
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if tx := db.txFromContext(ctx); tx != nil {
+		return tx.PrepareContext(ctx, query)
+	}
+
+	return db.DB.PrepareContext(ctx, query)
+}
+
+// txFromContext returns the transaction carried by ctx when it was started by this db.
+func (db *DB) txFromContext(ctx context.Context) *Tx {
+	if tx := TxFromContext(ctx); tx != nil && tx.owner == db.DB {
+		return tx
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package dbtest provides throwaway SQLite databases for tests: schema and fixtures
+// are loaded when the database is opened, every test runs in the transaction rolled back
+// at the cleanup and query results are compared with golden files.
+//
+// The package does not register the SQLite driver, tests import it themselves:
+//
+//	import _ "github.com/mattn/go-sqlite3"
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+//nolint:gochecknoglobals // used to create unique database names
+var (
+	counter       atomic.Uint64
+	nameCharRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// New opens the in-memory SQLite database which is closed at the cleanup of the test.
+// Connections of the database share the same data, so it can be used by concurrent queries.
+func New(tb testing.TB, opts ...Option) *db.DB {
+	tb.Helper()
+
+	o := &options{}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	name := fmt.Sprintf("%s_%d", nameCharRegex.ReplaceAllString(tb.Name(), "_"), counter.Add(1))
+	config := &db.Config{
+		Database: "file:" + name,
+		Driver:   db.SQLite,
+		Params:   map[string]string{"mode": "memory", "cache": "shared", "_foreign_keys": "on"},
+	}
+
+	database, err := db.New(append([]db.Option{db.WithConfig(config)}, o.dbOptions...)...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// the in-memory database is removed with its last connection, so one connection is kept open
+	keeper, err := database.Conn(ctx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() {
+		_ = keeper.Close()
+		_ = database.Close()
+	})
+
+	// the schema and fixtures belong to all tenants, so the setup passes the tenant guard
+	for _, setup := range o.setup {
+		if err = setup(db.WithoutTenant(ctx), database); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	return database
+}
+
+// Begin starts the transaction which is rolled back at the cleanup of the test. Queries must use
+// the transaction or its context, tx.Context(), otherwise they fail with the locked table error.
+func Begin(tb testing.TB, database *db.DB) *db.Tx {
+	tb.Helper()
+
+	tx, err := database.BeginTx(context.Background(), nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() {
+		_ = tx.Rollback()
+	})
+
+	return tx
+}
@@ -0,0 +1,93 @@
+package dbtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/kamilov/go-kit/coder/yaml"
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newDB(t *testing.T) *db.DB {
+	t.Helper()
+
+	return dbtest.New(t,
+		dbtest.WithMigrations(fstest.MapFS{
+			"1_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)")},
+			"1_users.down.sql": {Data: []byte("DROP TABLE users")},
+		}),
+		dbtest.WithFixtures(fstest.MapFS{
+			"1_users.yaml": {Data: []byte("- id: 1\n  name: foo\n- id: 2\n  name: bar\n")},
+			"2_users.sql":  {Data: []byte("INSERT INTO users (id, name) VALUES (3, 'baz')")},
+		}, "2_users.sql", "1_users.yaml"),
+	)
+}
+
+func TestDBTest(t *testing.T) {
+	database := newDB(t)
+
+	count := func(t *testing.T, ctx context.Context) int {
+		t.Helper()
+
+		var result int
+
+		if err := database.Get(ctx, &result, "SELECT COUNT(*) FROM users"); err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	t.Run("test fixtures", func(t *testing.T) {
+		if got := count(t, context.Background()); got != 3 {
+			t.Errorf("got %d users, want 3", got)
+		}
+	})
+
+	t.Run("test rollback", func(t *testing.T) {
+		t.Run("insert", func(t *testing.T) {
+			tx := dbtest.Begin(t, database)
+
+			if _, err := database.ExecContext(tx.Context(), "INSERT INTO users (id, name) VALUES (4, 'qux')"); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := count(t, tx.Context()); got != 4 {
+				t.Errorf("got %d users, want 4", got)
+			}
+		})
+
+		if got := count(t, context.Background()); got != 3 {
+			t.Errorf("got %d users after rollback, want 3", got)
+		}
+	})
+
+	t.Run("test golden", func(t *testing.T) {
+		tx := dbtest.Begin(t, database)
+
+		dbtest.AssertGolden(tx.Context(), t, database, "users", "SELECT id, name FROM users ORDER BY id")
+	})
+
+	t.Run("test isolated databases", func(t *testing.T) {
+		other := dbtest.New(t, dbtest.WithSQL("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)"))
+
+		var result int
+
+		if err := other.Get(context.Background(), &result, "SELECT COUNT(*) FROM users"); err != nil {
+			t.Fatal(err)
+		} else if result != 0 {
+			t.Errorf("got %d users, want empty database", result)
+		}
+	})
+
+	t.Run("test unsupported fixture", func(t *testing.T) {
+		err := dbtest.LoadFixtures(context.Background(), database, fstest.MapFS{"users.csv": {Data: []byte("id\n1")}})
+		if !errors.Is(err, dbtest.ErrUnsupportedFixture) {
+			t.Errorf("got error %v, want %v", err, dbtest.ErrUnsupportedFixture)
+		}
+	})
+}
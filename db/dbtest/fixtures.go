@@ -0,0 +1,107 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kamilov/go-kit/coder"
+	"github.com/kamilov/go-kit/db"
+)
+
+var (
+	ErrUnsupportedFixture = errors.New("unsupported fixture format")
+
+	tablePrefixRegex = regexp.MustCompile(`^\d+_`)
+)
+
+// LoadFixtures executes `.sql` files and inserts rows of other files into the table named
+// after the file without the optional ordering prefix, e.g. `users.yaml` or `01_users.yaml`
+// contains the list of rows of the users table:
+//
+//   - id: 1
+//     name: foo
+//
+// Files are decoded by the coder registered for the file extension, so the coder package
+// must be imported, e.g. `_ "github.com/kamilov/go-kit/coder/yaml"`.
+func LoadFixtures(ctx context.Context, q db.Querier, fsys fs.FS, files ...string) error {
+	if len(files) == 0 {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, entry.Name())
+			}
+		}
+
+		sort.Strings(files)
+	}
+
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		if err = loadFixture(ctx, q, file, content); err != nil {
+			return fmt.Errorf("fixture %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func loadFixture(ctx context.Context, q db.Querier, file string, content []byte) error {
+	extension := path.Ext(file)
+	if extension == ".sql" {
+		_, err := q.ExecContext(ctx, string(content))
+		return err
+	}
+
+	decode := coder.GetDecoder(strings.TrimPrefix(extension, "."))
+	if decode == nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFixture, extension)
+	}
+
+	var rows []map[string]any
+
+	if err := decode(ctx, bytes.NewReader(content), &rows); err != nil {
+		return err
+	}
+
+	table := tablePrefixRegex.ReplaceAllString(strings.TrimSuffix(path.Base(file), extension), "")
+
+	for _, row := range rows {
+		columns := make([]string, 0, len(row))
+
+		for column := range row {
+			columns = append(columns, column)
+		}
+
+		sort.Strings(columns)
+
+		args := make([]any, len(columns))
+
+		for i, column := range columns {
+			args[i] = row[column]
+		}
+
+		query := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+			strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+		if _, err := q.ExecContext(ctx, db.Rebind(q.DriverName(), query), args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+const goldenDir = "testdata"
+
+//nolint:gochecknoglobals // the flag is registered for the tests importing the package
+var update = flag.Bool("dbtest.update", false, "update dbtest golden files")
+
+// AssertGolden compares the query result encoded as JSON with the `testdata/<name>.golden` file,
+// the file is written instead when tests run with the `-dbtest.update` flag.
+func AssertGolden(ctx context.Context, tb testing.TB, q db.Querier, name, query string, args ...any) {
+	tb.Helper()
+
+	result := make([]map[string]any, 0)
+
+	if err := q.Select(ctx, &result, query, args...); err != nil {
+		tb.Fatal(err)
+	}
+
+	actual, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	actual = append(actual, '\n')
+	file := filepath.Join(goldenDir, name+".golden")
+
+	if *update {
+		if err = os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+
+		if err = os.WriteFile(file, actual, 0o600); err != nil {
+			tb.Fatal(err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		tb.Fatalf("%v, run tests with -dbtest.update to create the golden file", err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		tb.Errorf("result of %q differs from %s:\ngot:\n%s\nwant:\n%s", query, file, actual, expected)
+	}
+}
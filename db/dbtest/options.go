@@ -0,0 +1,68 @@
+package dbtest
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/migrate"
+)
+
+type (
+	options struct {
+		dbOptions []db.Option
+		setup     []func(ctx context.Context, database *db.DB) error
+	}
+
+	optionFunc func(*options)
+
+	Option interface {
+		apply(*options)
+	}
+)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithDBOptions passes the options to db.New, e.g. hooks or the strict scan.
+func WithDBOptions(opts ...db.Option) Option {
+	return optionFunc(func(o *options) {
+		o.dbOptions = append(o.dbOptions, opts...)
+	})
+}
+
+// WithMigrations applies all up migrations from fsys using the migrate package.
+func WithMigrations(fsys fs.FS, opts ...migrate.Option) Option {
+	return optionFunc(func(o *options) {
+		o.setup = append(o.setup, func(ctx context.Context, database *db.DB) error {
+			_, err := migrate.New(database, fsys, opts...).Up(ctx)
+			return err
+		})
+	})
+}
+
+// WithSQL executes the queries, e.g. to create the schema without migrations.
+func WithSQL(queries ...string) Option {
+	return optionFunc(func(o *options) {
+		o.setup = append(o.setup, func(ctx context.Context, database *db.DB) error {
+			for _, query := range queries {
+				if _, err := database.ExecContext(ctx, query); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+// WithFixtures loads the fixture files from fsys in the given order or all files of the root
+// sorted by name when files are omitted, see LoadFixtures.
+func WithFixtures(fsys fs.FS, files ...string) Option {
+	return optionFunc(func(o *options) {
+		o.setup = append(o.setup, func(ctx context.Context, database *db.DB) error {
+			return LoadFixtures(ctx, database, fsys, files...)
+		})
+	})
+}
@@ -0,0 +1,382 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagOptionEncrypted     = "encrypted"
+	tagOptionDeterministic = "deterministic"
+
+	keyIDSeparator   = ":"
+	deterministicMAC = "deterministic nonce"
+)
+
+var (
+	ErrNoKeyring       = errors.New("keyring is not set, see WithKeyring")
+	ErrEncryptedTable  = errors.New("table of encrypted columns is not set, see WithEncryptedTable")
+	ErrKeyID           = errors.New("invalid encryption key id")
+	ErrUnknownKey      = errors.New("unknown encryption key")
+	ErrCiphertext      = errors.New("invalid ciphertext")
+	ErrEncryptedType   = errors.New("encrypted field must be a string or bytes")
+	ErrEncryptedFilter = errors.New("only deterministic encrypted columns can be filtered")
+)
+
+type (
+	// Keyring encrypts values with the primary key and decrypts them with any key,
+	// the ID of the key is stored in the ciphertext, so keys can be rotated by adding
+	// the new primary key and keeping the old ones until the data is re-encrypted.
+	Keyring struct {
+		primary string
+		keys    map[string]*encryptionKey
+	}
+
+	encryptionKey struct {
+		aead cipher.AEAD
+		mac  []byte
+	}
+
+	encryptedScanner struct {
+		dst     reflect.Value
+		keyring *Keyring
+		table   string
+		column  string
+	}
+
+	// encryptedValue is bound to the keyring and the table by the querier running the query.
+	encryptedValue struct {
+		rv            reflect.Value
+		deterministic bool
+		keyring       *Keyring
+		table         string
+		column        string
+	}
+
+	encryptedTableKey struct{}
+)
+
+// NewKeyring creates the keyring of AES-128, AES-192 or AES-256 keys by their IDs, values are
+// encrypted with the primary key. IDs are stored in every ciphertext, so they must be short
+// and must not contain the colon.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, primary)
+	}
+
+	k := &Keyring{primary: primary, keys: make(map[string]*encryptionKey, len(keys))}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, keyIDSeparator) {
+			return nil, fmt.Errorf("%w: %q", ErrKeyID, id)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(deterministicMAC))
+
+		k.keys[id] = &encryptionKey{aead: aead, mac: mac.Sum(nil)}
+	}
+
+	return k, nil
+}
+
+// WithKeyring sets the keyring of the fields tagged with the `encrypted` option:
+//
+//	type Patient struct {
+//		ID    int64   `db:"id,pk,auto"`
+//		SSN   string  `db:"ssn,encrypted"`
+//		Email *string `db:"email,encrypted,deterministic"`
+//	}
+//
+// Values are encrypted with AES-GCM and a random nonce, so equal values have different
+// ciphertexts. The `deterministic` option derives the nonce from the value, equal values
+// have equal ciphertexts and the column can be used in WithFilter, at the cost of revealing
+// which rows have equal values. The table and the column are authenticated with the value,
+// so the ciphertext copied into another column or table fails to decrypt.
+func WithKeyring(k *Keyring) Option {
+	return optionFunc(func(o *options) {
+		o.keyring = k
+	})
+}
+
+// WithEncryptedTable returns the context binding the encrypted columns of raw queries to the
+// table, repositories and inserts bind their own table.
+func WithEncryptedTable(ctx context.Context, table string) context.Context {
+	return context.WithValue(ctx, encryptedTableKey{}, table)
+}
+
+func encryptedTable(ctx context.Context) string {
+	table, _ := ctx.Value(encryptedTableKey{}).(string)
+	return table
+}
+
+// Encrypt encrypts the plaintext with the primary key, the result is `<key id>:<base64>`.
+// The additional data is authenticated but not stored, it must be passed to Decrypt as well.
+func (k *Keyring) Encrypt(plaintext, additionalData []byte, deterministic bool) (string, error) {
+	return k.encrypt(k.primary, plaintext, additionalData, deterministic)
+}
+
+// Decrypt decrypts the ciphertext with the key which encrypted it.
+func (k *Keyring) Decrypt(ciphertext string, additionalData []byte) ([]byte, error) {
+	id, data, ok := strings.Cut(ciphertext, keyIDSeparator)
+	if !ok {
+		return nil, ErrCiphertext
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(raw) < key.aead.NonceSize() {
+		return nil, ErrCiphertext
+	}
+
+	nonce, sealed := raw[:key.aead.NonceSize()], raw[key.aead.NonceSize():]
+
+	plaintext, err := key.aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, errors.Join(ErrCiphertext, err)
+	}
+
+	return plaintext, nil
+}
+
+// Lookup returns the deterministic ciphertexts of the plaintext with every key, so the rows
+// encrypted before the rotation are found as well.
+func (k *Keyring) Lookup(plaintext, additionalData []byte) ([]string, error) {
+	ids := make([]string, 0, len(k.keys))
+
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	result := make([]string, len(ids))
+
+	for i, id := range ids {
+		ciphertext, err := k.encrypt(id, plaintext, additionalData, true)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = ciphertext
+	}
+
+	return result, nil
+}
+
+func (k *Keyring) encrypt(id string, plaintext, additionalData []byte, deterministic bool) (string, error) {
+	key := k.keys[id]
+	nonce := make([]byte, key.aead.NonceSize())
+
+	if deterministic {
+		// the additional data is a part of the nonce, so values of different columns never share it
+		mac := hmac.New(sha256.New, key.mac)
+		mac.Write([]byte(strconv.Itoa(len(additionalData)) + keyIDSeparator))
+		mac.Write(additionalData)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := key.aead.Seal(nonce, nonce, plaintext, additionalData)
+
+	return id + keyIDSeparator + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Scan decrypts the column into the string or bytes field, NULL resets the field to the zero value.
+func (s *encryptedScanner) Scan(src any) error {
+	var ciphertext string
+
+	switch value := src.(type) {
+	case nil:
+		s.dst.Set(reflect.Zero(s.dst.Type()))
+		return nil
+	case []byte:
+		ciphertext = string(value)
+	case string:
+		ciphertext = value
+	default:
+		return fmt.Errorf("%w: %T", ErrColumnType, src)
+	}
+
+	additionalData, err := encryptedData(s.keyring, s.table, s.column)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := s.keyring.Decrypt(ciphertext, additionalData)
+	if err != nil {
+		return err
+	}
+
+	dst := s.dst
+
+	if dst.Kind() == reflect.Pointer {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+
+	switch {
+	case dst.Kind() == reflect.String:
+		dst.SetString(string(plaintext))
+	case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+		dst.SetBytes(plaintext)
+	default:
+		return fmt.Errorf("%w: %s", ErrEncryptedType, s.dst.Type())
+	}
+
+	return nil
+}
+
+// Value encrypts the value with the primary key, nil pointers are stored as NULL.
+func (v encryptedValue) Value() (driver.Value, error) {
+	plaintext, ok, err := encryptedPlaintext(v.rv)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	additionalData, err := encryptedData(v.keyring, v.table, v.column)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.keyring.Encrypt(plaintext, additionalData, v.deterministic)
+}
+
+// bindEncrypted binds the encrypted arguments to the keyring and the table of the context.
+func (s settings) bindEncrypted(ctx context.Context, args []any) []any {
+	var result []any
+
+	for i, arg := range args {
+		value, ok := arg.(encryptedValue)
+		if !ok {
+			continue
+		}
+
+		if result == nil {
+			result = slices.Clone(args)
+		}
+
+		value.keyring = s.keyring
+		value.table = encryptedTable(ctx)
+		result[i] = value
+	}
+
+	if result == nil {
+		return args
+	}
+
+	return result
+}
+
+// encryptedData returns the additional data of the column authenticated with its values.
+func encryptedData(k *Keyring, table, column string) ([]byte, error) {
+	switch {
+	case k == nil:
+		return nil, ErrNoKeyring
+	case table == "":
+		return nil, fmt.Errorf("%w: %s", ErrEncryptedTable, column)
+	default:
+		return []byte(table + "." + column), nil
+	}
+}
+
+// encryptedFilter replaces the filter value of the deterministic column with its ciphertexts.
+func encryptedFilter(k *Keyring, table string, f *field, value any) (any, error) {
+	if !f.options.Has(tagOptionDeterministic) {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptedFilter, f.name)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	values, err := expandValue(value)
+	if err != nil {
+		// the empty list never matches
+		return value, nil //nolint:nilerr // handled by filterCondition
+	}
+
+	additionalData, err := encryptedData(k, table, f.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, item := range values {
+		plaintext, ok, err := encryptedPlaintext(reflect.ValueOf(item))
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		ciphertexts, err := k.Lookup(plaintext, additionalData)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, ciphertexts...)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func encryptedPlaintext(rv reflect.Value) ([]byte, bool, error) {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	switch {
+	case !rv.IsValid():
+		return nil, false, nil
+	case rv.Kind() == reflect.String:
+		return []byte(rv.String()), true, nil
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+
+		return rv.Bytes(), true, nil
+	default:
+		return nil, false, fmt.Errorf("%w: %s", ErrEncryptedType, rv.Type())
+	}
+}
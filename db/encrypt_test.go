@@ -0,0 +1,249 @@
+package db_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type encryptedPatient struct {
+	ID    int64   `db:"id,pk,auto"`
+	SSN   string  `db:"ssn,encrypted"`
+	Email *string `db:"email,encrypted,deterministic"`
+	Notes []byte  `db:"notes,encrypted"`
+}
+
+const encryptedSchema = "CREATE TABLE patients (id INTEGER PRIMARY KEY AUTOINCREMENT, ssn TEXT, email TEXT, notes TEXT)"
+
+func newKeyring(t *testing.T, primary string, ids ...string) *db.Keyring {
+	t.Helper()
+
+	keys := make(map[string][]byte, len(ids))
+
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte(id[len(id)-1:]), 32)
+	}
+
+	k, err := db.NewKeyring(primary, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestKeyring(t *testing.T) {
+	k := newKeyring(t, "v1", "v1")
+	ssn := []byte("patients.ssn")
+
+	t.Run("test round trip", func(t *testing.T) {
+		t.Helper()
+
+		first, err := k.Encrypt([]byte("secret"), ssn, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, _ := k.Encrypt([]byte("secret"), ssn, false)
+
+		if first == second || !strings.HasPrefix(first, "v1:") {
+			t.Errorf("got %q and %q, want different ciphertexts of the v1 key", first, second)
+		}
+
+		plaintext, err := k.Decrypt(first, ssn)
+		if err != nil || string(plaintext) != "secret" {
+			t.Errorf("got %q and %v, want secret", plaintext, err)
+		}
+
+		if _, err = k.Decrypt(first, []byte("patients.notes")); !errors.Is(err, db.ErrCiphertext) {
+			t.Errorf("got %v, want %v", err, db.ErrCiphertext)
+		}
+	})
+
+	t.Run("test deterministic", func(t *testing.T) {
+		t.Helper()
+
+		first, _ := k.Encrypt([]byte("secret"), ssn, true)
+		second, _ := k.Encrypt([]byte("secret"), ssn, true)
+		other, _ := k.Encrypt([]byte("other"), ssn, true)
+		column, _ := k.Encrypt([]byte("secret"), []byte("patients.notes"), true)
+
+		if first != second || first == other || first == column {
+			t.Errorf("got %q, %q, %q and %q, want equal ciphertexts of equal values of the column only",
+				first, second, other, column)
+		}
+	})
+
+	t.Run("test invalid ciphertext", func(t *testing.T) {
+		t.Helper()
+
+		ciphertext, _ := k.Encrypt([]byte("secret"), ssn, false)
+		tampered := ciphertext[:5] + string(ciphertext[5]^1) + ciphertext[6:]
+
+		for _, value := range []string{"plain", "v1:!!", tampered} {
+			if _, err := k.Decrypt(value, ssn); !errors.Is(err, db.ErrCiphertext) {
+				t.Errorf("%q: got %v, want %v", value, err, db.ErrCiphertext)
+			}
+		}
+
+		if _, err := k.Decrypt("v9:AAAA", ssn); !errors.Is(err, db.ErrUnknownKey) {
+			t.Errorf("got %v, want %v", err, db.ErrUnknownKey)
+		}
+	})
+	t.Run("test invalid keys", func(t *testing.T) {
+		t.Helper()
+
+		if _, err := db.NewKeyring("v2", map[string][]byte{"v1": make([]byte, 32)}); !errors.Is(err, db.ErrUnknownKey) {
+			t.Errorf("got %v, want %v", err, db.ErrUnknownKey)
+		}
+
+		if _, err := db.NewKeyring("a:b", map[string][]byte{"a:b": make([]byte, 32)}); !errors.Is(err, db.ErrKeyID) {
+			t.Errorf("got %v, want %v", err, db.ErrKeyID)
+		}
+
+		if _, err := db.NewKeyring("v1", map[string][]byte{"v1": make([]byte, 7)}); err == nil {
+			t.Error("invalid key size is accepted")
+		}
+	})
+}
+
+func TestEncryptedFields(t *testing.T) {
+	ctx := context.Background()
+	email := "jane@example.com"
+	jane := &encryptedPatient{SSN: "123-45-6789", Email: &email, Notes: []byte("allergic")}
+
+	plain, err := db.NewRepository[encryptedPatient, int64](dbtest.New(t, dbtest.WithSQL(encryptedSchema)), "patients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = plain.Create(ctx, jane); !errors.Is(err, db.ErrNoKeyring) {
+		t.Fatalf("got %v, want %v", err, db.ErrNoKeyring)
+	}
+
+	testDB := dbtest.New(t, dbtest.WithSQL(encryptedSchema), dbtest.WithDBOptions(db.WithKeyring(newKeyring(t, "v2", "v1", "v2"))))
+
+	patients, err := db.NewRepository[encryptedPatient, int64](testDB, "patients")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = patients.Create(ctx, jane); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test stored ciphertext", func(t *testing.T) {
+		t.Helper()
+
+		var ssn string
+
+		if err := testDB.Get(ctx, &ssn, "SELECT ssn FROM patients WHERE id = ?", jane.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(ssn, "v2:") || strings.Contains(ssn, "6789") {
+			t.Errorf("got %q, want the ciphertext", ssn)
+		}
+
+		got, err := patients.Get(ctx, jane.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.SSN != jane.SSN || got.Email == nil || *got.Email != email || string(got.Notes) != "allergic" {
+			t.Errorf("got %+v, want %+v", got, jane)
+		}
+	})
+
+	t.Run("test raw query", func(t *testing.T) {
+		t.Helper()
+
+		var got encryptedPatient
+
+		query := "SELECT id, ssn FROM patients WHERE id = ?"
+
+		if err := testDB.Get(ctx, &got, query, jane.ID); !errors.Is(err, db.ErrEncryptedTable) {
+			t.Errorf("got %v, want %v", err, db.ErrEncryptedTable)
+		}
+
+		if err := testDB.Get(db.WithEncryptedTable(ctx, "patients"), &got, query, jane.ID); err != nil || got.SSN != jane.SSN {
+			t.Errorf("got %+v and %v, want %+v", got, err, jane)
+		}
+	})
+
+	t.Run("test copied ciphertext", func(t *testing.T) {
+		t.Helper()
+
+		copied := &encryptedPatient{SSN: "111-11-1111"}
+
+		if err := patients.Create(ctx, copied); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := testDB.Exec("UPDATE patients SET notes = ssn WHERE id = ?", copied.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := patients.Get(ctx, copied.ID); !errors.Is(err, db.ErrCiphertext) {
+			t.Errorf("got %v, want %v", err, db.ErrCiphertext)
+		}
+
+		if err := patients.ForceDelete(ctx, copied.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("test null", func(t *testing.T) {
+		t.Helper()
+
+		john := &encryptedPatient{SSN: "987-65-4321"}
+
+		if err := patients.Create(ctx, john); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := patients.Get(ctx, john.ID)
+		if err != nil || got.Email != nil || got.Notes != nil {
+			t.Errorf("got %+v and %v, want empty email and notes", got, err)
+		}
+	})
+
+	t.Run("test filter", func(t *testing.T) {
+		t.Helper()
+
+		items, err := patients.List(ctx, db.WithFilter("email", email))
+		if err != nil || len(items) != 1 || items[0].ID != jane.ID {
+			t.Errorf("got %+v and %v, want jane", items, err)
+		}
+
+		if _, err = patients.List(ctx, db.WithFilter("ssn", jane.SSN)); !errors.Is(err, db.ErrEncryptedFilter) {
+			t.Errorf("got %v, want %v", err, db.ErrEncryptedFilter)
+		}
+	})
+
+	t.Run("test key rotation", func(t *testing.T) {
+		t.Helper()
+
+		old := newKeyring(t, "v1", "v1")
+		other := "max@example.com"
+
+		ssn, _ := old.Encrypt([]byte("000-00-0000"), []byte("patients.ssn"), false)
+		oldEmail, _ := old.Encrypt([]byte(other), []byte("patients.email"), true)
+
+		_, err := testDB.Exec("INSERT INTO patients (ssn, email) VALUES (?, ?)", ssn, oldEmail)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		items, err := patients.List(ctx, db.WithFilter("email", []string{email, other}), db.WithOrderBy("id"))
+		if err != nil || len(items) != 2 || items[0].SSN != jane.SSN || items[1].SSN != "000-00-0000" {
+			t.Errorf("got %+v and %v, want jane and max", items, err)
+		}
+	})
+}
@@ -1,8 +1,24 @@
 module github.com/kamilov/go-kit/db
 
-go 1.22
+go 1.23
 
 require (
+	github.com/kamilov/go-kit/bus v0.0.0
+	github.com/kamilov/go-kit/coder v0.0.0
+	github.com/kamilov/go-kit/endpoint v0.0.0
+	github.com/kamilov/go-kit/utils v0.0.0
 	github.com/loghole/dbhook v0.5.0
 	github.com/mattn/go-sqlite3 v1.14.16
 )
+
+require (
+	github.com/google/go-cmp v0.6.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/kamilov/go-kit/bus => ../bus
+	github.com/kamilov/go-kit/coder => ../coder
+	github.com/kamilov/go-kit/endpoint => ../endpoint
+	github.com/kamilov/go-kit/utils => ../utils
+)
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrUnhealthy = errors.New("database is unhealthy")
+
+type (
+	// Stats is the state of the connection pools, it can be encoded to JSON as is
+	// and returned from the health endpoint.
+	Stats struct {
+		Primary  PoolStats      `json:"primary"`
+		Replicas []ReplicaStats `json:"replicas,omitempty"`
+	}
+
+	PoolStats struct {
+		MaxOpenConnections int           `json:"max_open_connections"`
+		OpenConnections    int           `json:"open_connections"`
+		InUse              int           `json:"in_use"`
+		Idle               int           `json:"idle"`
+		WaitCount          int64         `json:"wait_count"`
+		WaitDuration       time.Duration `json:"wait_duration"`
+		MaxIdleClosed      int64         `json:"max_idle_closed"`
+		MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+		MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+	}
+
+	ReplicaStats struct {
+		PoolStats
+		Healthy bool          `json:"healthy"`
+		Latency time.Duration `json:"latency"`
+	}
+)
+
+// Health pings the primary within the health check timeout, unhealthy replicas don't fail
+// the check because read queries fall back to the primary.
+func (db *DB) Health(ctx context.Context) error {
+	if db.healthTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, db.healthTimeout)
+		defer cancel()
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnhealthy, err)
+	}
+
+	return nil
+}
+
+// PoolStats returns the statistics of the primary and replica pools, the embedded Stats
+// of sql.DB returns the statistics of the primary pool only.
+func (db *DB) PoolStats() Stats {
+	stats := Stats{Primary: newPoolStats(db.DB.Stats())}
+
+	if db.replicas != nil {
+		stats.Replicas = make([]ReplicaStats, len(db.replicas.replicas))
+
+		for i, item := range db.replicas.replicas {
+			stats.Replicas[i] = ReplicaStats{
+				PoolStats: newPoolStats(item.db.Stats()),
+				Healthy:   item.healthy.Load(),
+				Latency:   time.Duration(item.latency.Load()),
+			}
+		}
+	}
+
+	return stats
+}
+
+func newPoolStats(stats sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,178 @@
+package db_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/utils/structure"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type envData map[string]string
+
+func (d envData) Get(key string) string {
+	return d[key]
+}
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test pool config from env", func(t *testing.T) {
+		t.Helper()
+
+		var config db.Config
+
+		err := structure.NewDecoder("env").Decode(envData{
+			"DB_DATABASE":           ":memory:",
+			"DB_DRIVER":             "sqlite3",
+			"DB_MAX_OPEN_CONNS":     "3",
+			"DB_MAX_IDLE_CONNS":     "2",
+			"DB_CONN_MAX_LIFETIME":  "5m",
+			"DB_CONN_MAX_IDLE_TIME": "30s",
+		}, &config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.MaxOpenConns != 3 || config.MaxIdleConns != 2 ||
+			config.ConnMaxLifetime != 5*time.Minute || config.ConnMaxIdleTime != 30*time.Second {
+			t.Fatalf("invalid pool config: %+v", config)
+		}
+
+		testDB, err := db.New(db.WithConfig(&config))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if err = testDB.Health(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		stats := testDB.PoolStats()
+
+		if stats.Primary.MaxOpenConnections != 3 {
+			t.Errorf("got %d max open connections, want 3", stats.Primary.MaxOpenConnections)
+		}
+
+		if stats.Primary.OpenConnections != 1 || stats.Primary.Idle != 1 {
+			t.Errorf("got %+v, want one idle connection", stats.Primary)
+		}
+	})
+
+	t.Run("test prefixed pool config without idle connections", func(t *testing.T) {
+		t.Helper()
+
+		config, err := db.DecodeConfig(envData{
+			"DB_DATABASE":               "primary.db",
+			"REPLICA_DB_DATABASE":       ":memory:",
+			"REPLICA_DB_DRIVER":         "sqlite3",
+			"REPLICA_DB_ROLE":           "replica",
+			"REPLICA_DB_MAX_IDLE_CONNS": "-1",
+		}, "REPLICA_")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Database != ":memory:" || config.Role != db.RoleReplica || config.MaxIdleConns != -1 {
+			t.Fatalf("invalid replica config: %+v", config)
+		}
+
+		config.Role = db.RolePrimary
+
+		testDB, err := db.New(db.WithConfig(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if err = testDB.Health(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if stats := testDB.PoolStats(); stats.Primary.Idle != 0 {
+			t.Errorf("got %+v, want no idle connections", stats.Primary)
+		}
+	})
+
+	t.Run("test stats with replicas", func(t *testing.T) {
+		t.Helper()
+
+		testDB, err := db.New(
+			db.WithConfig(&db.Config{Database: ":memory:", Driver: db.SQLite}),
+			db.WithConfig(&db.Config{
+				Database: filepath.Join(t.TempDir(), "missing", "replica.db"),
+				Driver:   db.SQLite,
+				Role:     db.RoleReplica,
+			}),
+			db.WithHealthCheck(10*time.Millisecond, time.Second),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if err = testDB.Health(ctx); err != nil {
+			t.Fatalf("unhealthy replica must not fail the health check: %v", err)
+		}
+
+		var stats db.Stats
+
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if stats = testDB.PoolStats(); len(stats.Replicas) == 1 && !stats.Replicas[0].Healthy {
+				break
+			}
+		}
+
+		if len(stats.Replicas) != 1 || stats.Replicas[0].Healthy {
+			t.Fatalf("got %+v, want one unhealthy replica", stats.Replicas)
+		}
+
+		data, err := json.Marshal(stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded map[string]any
+
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		} else if _, ok := decoded["replicas"].([]any)[0].(map[string]any)["healthy"]; !ok {
+			t.Errorf("replica health is not encoded: %s", data)
+		}
+	})
+
+	t.Run("test unhealthy primary", func(t *testing.T) {
+		t.Helper()
+
+		testDB, err := db.New(db.WithConfig(&db.Config{
+			Database: filepath.Join(t.TempDir(), "missing", "primary.db"),
+			Driver:   db.SQLite,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if err = testDB.Health(ctx); !errors.Is(err, db.ErrUnhealthy) {
+			t.Errorf("got error %v, want %v", err, db.ErrUnhealthy)
+		}
+	})
+}
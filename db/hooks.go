@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/loghole/dbhook"
+)
+
+const redactedValue = "[REDACTED]"
+
+var (
+	normalizeStringRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+	normalizeNumberRegex = regexp.MustCompile(`\b\d+(?:\.\d+)?\b|\$\d+`)
+	normalizeListRegex   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	normalizeSpaceRegex  = regexp.MustCompile(`\s+`)
+)
+
+type (
+	// QueryInfo describes the finished query passed to the built-in hooks.
+	QueryInfo struct {
+		// Query is the original query text.
+		Query string
+		// Statement is the normalized query text without literals, see NormalizeQuery.
+		Statement string
+		Caller    dbhook.CallerType
+		Args      []any
+		Duration  time.Duration
+		Err       error
+	}
+
+	// Redactor replaces sensitive arguments before they are logged.
+	Redactor func(args []any) []any
+
+	// Metrics receives per-query measurements keyed by the normalized statement.
+	Metrics interface {
+		ObserveQuery(ctx context.Context, info QueryInfo)
+	}
+
+	// RowsObserver is an optional interface of Metrics receiving the number of rows
+	// scanned by Select, Get and Query or affected by Exec.
+	RowsObserver interface {
+		ObserveRows(ctx context.Context, statement string, rows int64)
+	}
+
+	// Tracer creates a span for every query, it can be adapted to OpenTelemetry or any other tracer.
+	Tracer interface {
+		Start(ctx context.Context, info QueryInfo) (context.Context, Span)
+	}
+
+	Span interface {
+		End(err error)
+	}
+
+	queryHook struct {
+		after func(ctx context.Context, info QueryInfo)
+	}
+
+	traceHook struct {
+		tracer Tracer
+	}
+
+	startContextKey struct{}
+	spanContextKey  struct{}
+)
+
+// WithQueryLogger logs every query with its duration, failed queries are logged with the error level.
+// Arguments are passed through the redactor, the nil redactor hides all of them. The redactor
+// covers arguments only, the query text is logged as is, so secrets must never be inlined
+// into the query as literals.
+func WithQueryLogger(logger *slog.Logger, redactor Redactor) Option {
+	if redactor == nil {
+		redactor = RedactAll
+	}
+
+	return WithHook(&queryHook{after: func(ctx context.Context, info QueryInfo) {
+		level := slog.LevelDebug
+		attrs := []slog.Attr{
+			slog.String("query", info.Query),
+			slog.String("caller", string(info.Caller)),
+			slog.Any("args", redactor(info.Args)),
+			slog.Duration("duration", info.Duration),
+		}
+
+		if info.Err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", info.Err.Error()))
+		}
+
+		logger.LogAttrs(ctx, level, "sql query", attrs...)
+	}})
+}
+
+// WithSlowQuery reports queries running longer than the threshold.
+func WithSlowQuery(threshold time.Duration, report func(ctx context.Context, info QueryInfo)) Option {
+	return WithHook(&queryHook{after: func(ctx context.Context, info QueryInfo) {
+		if info.Duration >= threshold {
+			report(ctx, info)
+		}
+	}})
+}
+
+// WithMetrics passes the latency and the error of every query to metrics, if metrics implement
+// RowsObserver the number of rows is reported by the kit helpers as well.
+func WithMetrics(metrics Metrics) Option {
+	return optionFunc(func(o *options) {
+		WithHook(&queryHook{after: metrics.ObserveQuery}).apply(o)
+
+		if observer, ok := metrics.(RowsObserver); ok {
+			o.rowsObserver = observer
+		}
+	})
+}
+
+// WithTracer starts a span for every query.
+func WithTracer(tracer Tracer) Option {
+	return WithHook(&traceHook{tracer: tracer})
+}
+
+// RedactAll replaces all arguments with the redacted placeholder.
+func RedactAll(args []any) []any {
+	result := make([]any, len(args))
+
+	for i := range result {
+		result[i] = redactedValue
+	}
+
+	return result
+}
+
+// RedactNone keeps the arguments as is.
+func RedactNone(args []any) []any {
+	return args
+}
+
+// NormalizeQuery replaces literals and placeholders with `?`, collapses lists of values
+// and whitespaces, so the same statement with different arguments has the same text.
+func NormalizeQuery(query string) string {
+	query = normalizeStringRegex.ReplaceAllString(query, "?")
+	query = normalizeNumberRegex.ReplaceAllString(query, "?")
+	query = normalizeListRegex.ReplaceAllString(query, "(?)")
+	query = normalizeSpaceRegex.ReplaceAllString(query, " ")
+
+	return strings.TrimSpace(query)
+}
+
+func (h *queryHook) Before(ctx context.Context, _ *dbhook.HookInput) (context.Context, error) {
+	return withStart(ctx), nil
+}
+
+func (h *queryHook) After(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	h.after(ctx, newQueryInfo(ctx, input))
+	return ctx, nil
+}
+
+// Error reports the failed query and keeps the error, dbhook skips After hooks for failed queries.
+func (h *queryHook) Error(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	h.after(ctx, newQueryInfo(ctx, input))
+	return ctx, input.Error
+}
+
+func (h *traceHook) Before(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	ctx = withStart(ctx)
+
+	ctx, span := h.tracer.Start(ctx, newQueryInfo(ctx, input))
+
+	return context.WithValue(ctx, spanContextKey{}, span), nil
+}
+
+func (h *traceHook) After(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	endSpan(ctx, input.Error)
+	return ctx, nil
+}
+
+func (h *traceHook) Error(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	endSpan(ctx, input.Error)
+	return ctx, input.Error
+}
+
+func endSpan(ctx context.Context, err error) {
+	if span, ok := ctx.Value(spanContextKey{}).(Span); ok {
+		span.End(err)
+	}
+}
+
+func withStart(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(startContextKey{}).(time.Time); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, startContextKey{}, time.Now())
+}
+
+func newQueryInfo(ctx context.Context, input *dbhook.HookInput) QueryInfo {
+	info := QueryInfo{
+		Query:     input.Query,
+		Statement: NormalizeQuery(input.Query),
+		Caller:    input.Caller,
+		Args:      driverArgs(input.Args),
+		Err:       input.Error,
+	}
+
+	if info.Statement == "" {
+		info.Statement = strings.ToUpper(string(input.Caller))
+	}
+
+	if start, ok := ctx.Value(startContextKey{}).(time.Time); ok {
+		info.Duration = time.Since(start)
+	}
+
+	return info
+}
+
+func driverArgs(values []driver.Value) []any {
+	args := make([]any, len(values))
+
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
+
+func (s settings) observeRows(ctx context.Context, query string, rows int64) {
+	if s.rowsObserver != nil {
+		s.rowsObserver.ObserveRows(ctx, NormalizeQuery(query), rows)
+	}
+}
+
+func (s settings) observeResult(ctx context.Context, query string, result sql.Result) {
+	if s.rowsObserver == nil {
+		return
+	}
+
+	if rows, err := result.RowsAffected(); err == nil {
+		s.rowsObserver.ObserveRows(ctx, NormalizeQuery(query), rows)
+	}
+}
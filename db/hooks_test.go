@@ -0,0 +1,258 @@
+package db_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type (
+	testMetrics struct {
+		mu      sync.Mutex
+		queries []db.QueryInfo
+		rows    map[string]int64
+	}
+
+	testTracer struct {
+		started []string
+		ended   []error
+	}
+
+	testSpan struct {
+		tracer *testTracer
+	}
+)
+
+func (m *testMetrics) ObserveQuery(_ context.Context, info db.QueryInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queries = append(m.queries, info)
+}
+
+func (m *testMetrics) ObserveRows(_ context.Context, statement string, rows int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rows[statement] += rows
+}
+
+func (t *testTracer) Start(ctx context.Context, info db.QueryInfo) (context.Context, db.Span) {
+	t.started = append(t.started, info.Statement)
+	return ctx, &testSpan{tracer: t}
+}
+
+func (s *testSpan) End(err error) {
+	s.tracer.ended = append(s.tracer.ended, err)
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		result string
+	}{
+		{"SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT *\n\tFROM users  WHERE name = 'it''s' AND age > 1.5", "SELECT * FROM users WHERE name = ? AND age > ?"},
+		{"SELECT * FROM users WHERE id IN ($1, $2, $3)", "SELECT * FROM users WHERE id IN (?)"},
+		{"INSERT INTO users (id, name) VALUES (?, ?)", "INSERT INTO users (id, name) VALUES (?)"},
+		{"SELECT * FROM table2 WHERE col1 = ?", "SELECT * FROM table2 WHERE col1 = ?"},
+	}
+
+	for _, test := range tests {
+		if result := db.NormalizeQuery(test.query); result != test.result {
+			t.Errorf("got %q, want %q", result, test.result)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test query logger", func(t *testing.T) {
+		t.Helper()
+
+		var buf bytes.Buffer
+
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		testDB, err := db.New(
+			db.WithConfigDSN("sqlite://:memory:"),
+			db.WithQueryLogger(logger, nil),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if _, err = testDB.ExecContext(ctx, "SELECT ?", "secret"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.ExecContext(ctx, "SELECT * FROM unknown"); err == nil {
+			t.Fatal("expected error")
+		}
+
+		output := buf.String()
+
+		switch {
+		case strings.Contains(output, "secret"):
+			t.Errorf("arguments are not redacted: %s", output)
+		case !strings.Contains(output, "level=DEBUG") || !strings.Contains(output, "[REDACTED]"):
+			t.Errorf("successful query is not logged: %s", output)
+		case !strings.Contains(output, "level=ERROR") || !strings.Contains(output, "no such table"):
+			t.Errorf("failed query is not logged: %s", output)
+		}
+	})
+
+	t.Run("test slow query", func(t *testing.T) {
+		t.Helper()
+
+		var slow []string
+
+		testDB, err := db.New(
+			db.WithConfigDSN("sqlite://:memory:"),
+			db.WithSlowQuery(0, func(_ context.Context, info db.QueryInfo) {
+				slow = append(slow, info.Statement)
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		if _, err = testDB.ExecContext(ctx, "SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(slow) != 1 || slow[0] != "SELECT ?" {
+			t.Errorf("got %v, want [SELECT ?]", slow)
+		}
+	})
+
+	t.Run("test metrics", func(t *testing.T) {
+		t.Helper()
+
+		metrics := &testMetrics{rows: make(map[string]int64)}
+
+		testDB, err := db.New(
+			db.WithConfigDSN("sqlite://:memory:"),
+			db.WithMetrics(metrics),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		testDB.SetMaxOpenConns(1)
+
+		if _, err = testDB.ExecContext(ctx, "CREATE TABLE foo (id INTEGER)"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.ExecContext(ctx, "INSERT INTO foo (id) VALUES (1), (2), (3)"); err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []int
+
+		if err = testDB.Select(ctx, &ids, "SELECT id FROM foo WHERE id > ?", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		for range db.Query[int](ctx, testDB, "SELECT id FROM foo") {
+			break
+		}
+
+		var value struct {
+			ID int `db:"id"`
+		}
+
+		if err = testDB.Get(ctx, &value, "SELECT 'foo' AS id"); err == nil {
+			t.Fatal("expected scan error")
+		}
+
+		if _, err = testDB.ExecContext(ctx, "SELECT * FROM unknown"); err == nil {
+			t.Fatal("expected error")
+		}
+
+		if rows := metrics.rows["INSERT INTO foo (id) VALUES (?), (?), (?)"]; rows != 3 {
+			t.Errorf("got %d inserted rows, want 3", rows)
+		}
+
+		if rows := metrics.rows["SELECT id FROM foo WHERE id > ?"]; rows != 2 {
+			t.Errorf("got %d selected rows, want 2", rows)
+		}
+
+		if rows := metrics.rows["SELECT id FROM foo"]; rows != 1 {
+			t.Errorf("got %d iterated rows, want 1", rows)
+		}
+
+		if rows, ok := metrics.rows["SELECT ? AS id"]; ok {
+			t.Errorf("got %d rows of the failed scan, want none", rows)
+		}
+
+		var failed int
+
+		for _, info := range metrics.queries {
+			if info.Err != nil {
+				failed++
+			}
+		}
+
+		if failed != 1 {
+			t.Errorf("got %d failed queries, want 1", failed)
+		}
+	})
+
+	t.Run("test tracer", func(t *testing.T) {
+		t.Helper()
+
+		tracer := &testTracer{}
+
+		testDB, err := db.New(
+			db.WithConfigDSN("sqlite://:memory:"),
+			db.WithTracer(tracer),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			_, err := tx.ExecContext(ctx, "SELECT 1")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"BEGIN", "SELECT ?", "COMMIT"}
+
+		if strings.Join(tracer.started, ",") != strings.Join(want, ",") {
+			t.Errorf("got spans %v, want %v", tracer.started, want)
+		}
+
+		if len(tracer.ended) != len(want) || errors.Join(tracer.ended...) != nil {
+			t.Errorf("got ended spans %v", tracer.ended)
+		}
+	})
+}
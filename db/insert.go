@@ -0,0 +1,482 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	tagOptionAuto = "auto"
+
+	postgresMaxParams  = 65535
+	sqliteMaxParams    = 32766
+	mysqlMaxParams     = 65535
+	sqlServerMaxParams = 2100
+	sqlServerMaxRows   = 1000
+	defaultMaxParams   = 999
+)
+
+var (
+	ErrInsertValue       = errors.New("insert value must be a struct or a slice of structs")
+	ErrInsertColumns     = errors.New("insert value has no columns to insert")
+	ErrUpsertUnsupported = errors.New("upsert is not supported by the driver")
+	ErrUpsertConflict    = errors.New("upsert requires conflict columns")
+)
+
+type (
+	insertOptions struct {
+		batchSize int
+		conflict  []string
+		update    []string
+		ignore    bool
+		upsert    bool
+	}
+
+	insertOptionFunc func(*insertOptions)
+
+	InsertOption interface {
+		apply(*insertOptions)
+	}
+)
+
+func (f insertOptionFunc) apply(o *insertOptions) {
+	f(o)
+}
+
+// WithBatchSize limits the number of rows inserted by a single statement,
+// by default batches are limited only by the driver parameter limit.
+func WithBatchSize(size int) InsertOption {
+	return insertOptionFunc(func(o *insertOptions) {
+		o.batchSize = size
+	})
+}
+
+// WithConflictIgnore skips rows conflicting on the given columns (or any constraint
+// when columns are omitted) instead of failing the statement.
+func WithConflictIgnore(columns ...string) InsertOption {
+	return insertOptionFunc(func(o *insertOptions) {
+		o.conflict = columns
+		o.ignore = true
+	})
+}
+
+// WithUpdateColumns limits the columns updated by Upsert on conflict.
+func WithUpdateColumns(columns ...string) InsertOption {
+	return insertOptionFunc(func(o *insertOptions) {
+		o.update = columns
+	})
+}
+
+// Insert inserts a struct into the table. Columns are derived from the `db` tags, fields
+// tagged with the `auto` option are generated by the database and scanned back into
+// the struct when it is passed by pointer and the driver supports RETURNING.
+func Insert(ctx context.Context, q Querier, table string, value any, opts ...InsertOption) error {
+	return insertValues(ctx, q, table, value, newInsertOptions(opts))
+}
+
+// InsertMany inserts a slice of structs using multi-row statements. Rows with generated
+// columns scanned back (see Insert) are inserted by a statement per row.
+func InsertMany(ctx context.Context, q Querier, table string, values any, opts ...InsertOption) error {
+	return insertValues(ctx, q, table, values, newInsertOptions(opts))
+}
+
+// Upsert inserts a struct or a slice of structs updating the existing rows
+// conflicting on the given columns. The columns are required by all drivers
+// except MySQL, which checks every unique key of the table and must be 8.0.19 or later.
+func Upsert(ctx context.Context, q Querier, table string, values any, conflict []string, opts ...InsertOption) error {
+	o := newInsertOptions(opts)
+	o.conflict = conflict
+	o.upsert = true
+
+	return insertValues(ctx, q, table, values, o)
+}
+
+func newInsertOptions(opts []InsertOption) *insertOptions {
+	o := &insertOptions{}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+func insertValues(ctx context.Context, q Querier, table string, values any, o *insertOptions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if auditTable := settingsOf(q).auditLog(ctx); auditTable != "" {
+		return audited(ctx, q, auditTable, func(ctx context.Context, q Querier) ([]*AuditEntry, error) {
+			if err := insertValues(ctx, q, table, values, o); err != nil {
+				return nil, err
+			}
+
+			return insertEntries(table, values, o)
+		})
+	}
+
+	ctx = WithEncryptedTable(ctx, table)
+
+	rows, err := structValues(values)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var columns, returning []*field
+
+	for _, f := range getStructMap(rows[0].Type()).fields {
+		if f.options.Has(tagOptionAuto) {
+			returning = append(returning, f)
+		} else {
+			columns = append(columns, f)
+		}
+	}
+
+	if len(columns) == 0 {
+		return ErrInsertColumns
+	}
+
+	drv := settingsOf(q).driver
+
+	if (o.upsert || o.ignore) && (drv == Clickhouse || drv == SQLServer) {
+		return ErrUpsertUnsupported
+	}
+
+	if o.upsert && len(o.conflict) == 0 && drv != MySQL {
+		return fmt.Errorf("%w: %s", ErrUpsertConflict, table)
+	}
+
+	if drv == Clickhouse {
+		return insertBatch(ctx, q, table, columns, rows)
+	}
+
+	if o.ignore || !supportsReturning(drv) || !rows[0].CanAddr() {
+		returning = nil
+	}
+
+	size := maxParams(drv) / len(columns)
+	if drv == SQLServer {
+		size = min(size, sqlServerMaxRows)
+	}
+
+	if o.batchSize > 0 && o.batchSize < size {
+		size = o.batchSize
+	}
+
+	// the order of rows returned by a multi-row statement is not guaranteed,
+	// so the rows with generated columns are inserted one by one
+	if len(returning) > 0 {
+		size = 1
+	}
+
+	for start := 0; start < len(rows); start += size {
+		batch := rows[start:min(start+size, len(rows))]
+		query, args := buildInsert(drv, table, columns, returning, batch, o)
+		query = Rebind(drv, query)
+
+		if len(returning) == 0 {
+			if _, err = q.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err = scanReturning(ctx, q, returning, batch[0], query, args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertEntries returns the audit entries of the inserted rows including the generated columns.
+func insertEntries(table string, values any, o *insertOptions) ([]*AuditEntry, error) {
+	rows, err := structValues(values)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	sm := getStructMap(rows[0].Type())
+	pk := sm.names[defaultPrimaryKey]
+
+	for _, f := range sm.fields {
+		if f.options.Has(tagOptionPK) {
+			pk = f
+		}
+	}
+
+	operation := AuditInsert
+	if o.upsert || o.ignore {
+		operation = AuditUpsert
+	}
+
+	entries := make([]*AuditEntry, len(rows))
+
+	for i, row := range rows {
+		entries[i] = newAuditEntry(operation, pk, sm.fields, row, reflect.Value{}, row)
+		entries[i].Table = table
+	}
+
+	return entries, nil
+}
+
+func scanReturning(ctx context.Context, q Querier, returning []*field, row reflect.Value, query string, args []any) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	s := settingsOf(q)
+	fields := make([]any, len(returning))
+
+	if rows.Next() {
+		for i, f := range returning {
+			fields[i] = f.scanTarget(ctx, s, fieldByIndex(row, f.index))
+		}
+
+		if err = rows.Scan(fields...); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func insertBatch(ctx context.Context, q Querier, table string, columns []*field, rows []reflect.Value) error {
+	if database, ok := q.(*DB); ok && database.txFromContext(ctx) == nil {
+		return database.TransactionalTx(ctx, nil, func(tx *Tx) error {
+			return insertBatch(ctx, tx, table, columns, rows)
+		})
+	}
+
+	stmt, err := q.PrepareContext(ctx, "INSERT INTO "+table+" ("+columnNames(columns)+")")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	s := settingsOf(q)
+
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, s.bindEncrypted(ctx, rowArgs(row, columns))...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func buildInsert(drv DriverName, table string, columns, returning []*field, rows []reflect.Value, o *insertOptions) (string, []any) {
+	var buf strings.Builder
+
+	args := make([]any, 0, len(rows)*len(columns))
+
+	buf.WriteString("INSERT INTO ")
+	buf.WriteString(table)
+	buf.WriteString(" (")
+	buf.WriteString(columnNames(columns))
+	buf.WriteString(") VALUES ")
+
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteByte('(')
+		buf.WriteString(strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+		buf.WriteByte(')')
+
+		args = append(args, rowArgs(row, columns)...)
+	}
+
+	buf.WriteString(conflictClause(drv, columns, o))
+
+	if len(returning) > 0 {
+		buf.WriteString(" RETURNING ")
+		buf.WriteString(columnNames(returning))
+	}
+
+	return buf.String(), args
+}
+
+func conflictClause(drv DriverName, columns []*field, o *insertOptions) string {
+	if !o.upsert && !o.ignore {
+		return ""
+	}
+
+	update := o.update
+
+	if len(update) == 0 {
+		for _, f := range columns {
+			if !slices.Contains(o.conflict, f.name) {
+				update = append(update, f.name)
+			}
+		}
+	}
+
+	if drv == MySQL {
+		return mysqlConflictClause(columns, update, o)
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString(" ON CONFLICT")
+
+	if len(o.conflict) > 0 {
+		buf.WriteString(" (")
+		buf.WriteString(strings.Join(o.conflict, ", "))
+		buf.WriteByte(')')
+	}
+
+	if o.ignore || len(update) == 0 {
+		buf.WriteString(" DO NOTHING")
+		return buf.String()
+	}
+
+	buf.WriteString(" DO UPDATE SET ")
+
+	for i, name := range update {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(name)
+		buf.WriteString(" = EXCLUDED.")
+		buf.WriteString(name)
+	}
+
+	return buf.String()
+}
+
+// mysqlConflictClause checks all unique keys of the table, so the conflict columns are not used.
+// Ignored rows assign the column to itself instead of INSERT IGNORE which hides other errors too.
+// Inserted values are referenced by the row alias of MySQL 8.0.19, VALUES() is deprecated.
+func mysqlConflictClause(columns []*field, update []string, o *insertOptions) string {
+	var buf strings.Builder
+
+	if o.ignore || len(update) == 0 {
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		buf.WriteString(columns[0].name)
+		buf.WriteString(" = ")
+		buf.WriteString(columns[0].name)
+
+		return buf.String()
+	}
+
+	buf.WriteString(" AS excluded ON DUPLICATE KEY UPDATE ")
+
+	for i, name := range update {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(name)
+		buf.WriteString(" = excluded.")
+		buf.WriteString(name)
+	}
+
+	return buf.String()
+}
+
+func structValues(values any) ([]reflect.Value, error) {
+	rv := reflect.ValueOf(values)
+
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, ErrInsertValue
+		}
+
+		rv = rv.Elem()
+	}
+
+	//nolint:exhaustive // process only struct and slice
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}, nil
+
+	case reflect.Slice:
+		result := make([]reflect.Value, 0, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+
+			for item.Kind() == reflect.Pointer {
+				item = item.Elem()
+			}
+
+			if item.Kind() != reflect.Struct {
+				return nil, ErrInsertValue
+			}
+
+			result = append(result, item)
+		}
+
+		return result, nil
+
+	default:
+		return nil, ErrInsertValue
+	}
+}
+
+func rowArgs(row reflect.Value, columns []*field) []any {
+	args := make([]any, len(columns))
+
+	for i, f := range columns {
+		args[i] = f.arg(valueByIndex(row, f.index))
+	}
+
+	return args
+}
+
+func columnNames(columns []*field) string {
+	names := make([]string, len(columns))
+
+	for i, f := range columns {
+		names[i] = f.name
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func supportsReturning(drv DriverName) bool {
+	//nolint:exhaustive // only these drivers support RETURNING
+	switch drv {
+	case PGX, Postgres, SQLite:
+		return true
+	default:
+		return false
+	}
+}
+
+func maxParams(drv DriverName) int {
+	//nolint:exhaustive // other drivers use the conservative limit
+	switch drv {
+	case PGX, Postgres:
+		return postgresMaxParams
+	case SQLite:
+		return sqliteMaxParams
+	case MySQL:
+		return mysqlMaxParams
+	case SQLServer:
+		return sqlServerMaxParams
+	default:
+		return defaultMaxParams
+	}
+}
@@ -0,0 +1,136 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type insertUser struct {
+	ID    int64  `db:"id,auto"`
+	Email string `db:"email"`
+	Name  string
+}
+
+func TestInsert(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("test insert returns generated id", func(t *testing.T) {
+		t.Helper()
+
+		user := insertUser{Email: "foo@example.com", Name: "foo"}
+
+		if err = db.Insert(ctx, testDB, "users", &user); err != nil {
+			t.Fatal(err)
+		} else if user.ID != 1 {
+			t.Errorf("got id %d, want 1", user.ID)
+		}
+
+		if err = db.Insert(ctx, testDB, "users", insertUser{Email: "bar@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("test insert many in batches", func(t *testing.T) {
+		t.Helper()
+
+		users := []insertUser{
+			{Email: "1@example.com"},
+			{Email: "2@example.com"},
+			{Email: "3@example.com"},
+			{Email: "4@example.com"},
+			{Email: "5@example.com"},
+		}
+
+		if err = db.InsertMany(ctx, testDB, "users", users, db.WithBatchSize(2)); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, user := range users {
+			var id int64
+
+			if user.ID != int64(i+3) {
+				t.Errorf("got id %d, want %d", user.ID, i+3)
+			} else if err = testDB.Get(ctx, &id, "SELECT id FROM users WHERE email = ?", user.Email); err != nil || id != user.ID {
+				t.Errorf("got id %d (%v) of %s, want %d", id, err, user.Email, user.ID)
+			}
+		}
+
+		var count int
+
+		if err = testDB.Get(ctx, &count, "SELECT COUNT(*) FROM users"); err != nil {
+			t.Error(err)
+		} else if count != 7 {
+			t.Errorf("got %d rows, want 7", count)
+		}
+	})
+
+	t.Run("test upsert", func(t *testing.T) {
+		t.Helper()
+
+		users := []*insertUser{
+			{Email: "foo@example.com", Name: "updated"},
+			{Email: "new@example.com", Name: "new"},
+		}
+
+		if err = db.Upsert(ctx, testDB, "users", users, []string{"email"}); err != nil {
+			t.Fatal(err)
+		}
+
+		var name string
+
+		if err = testDB.Get(ctx, &name, "SELECT name FROM users WHERE email = ?", "foo@example.com"); err != nil {
+			t.Error(err)
+		} else if name != "updated" {
+			t.Errorf("got %q, want %q", name, "updated")
+		}
+
+		if users[0].ID != 1 {
+			t.Errorf("got id %d, want 1", users[0].ID)
+		}
+
+		if err = db.Upsert(ctx, testDB, "users", users, nil); !errors.Is(err, db.ErrUpsertConflict) {
+			t.Errorf("got error %v, want %v", err, db.ErrUpsertConflict)
+		}
+	})
+
+	t.Run("test conflict ignore", func(t *testing.T) {
+		t.Helper()
+
+		user := insertUser{Email: "foo@example.com", Name: "ignored"}
+
+		if err = db.Insert(ctx, testDB, "users", &user, db.WithConflictIgnore("email")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = db.Insert(ctx, testDB, "users", &user); err == nil {
+			t.Error("expected unique constraint error")
+		}
+	})
+
+	t.Run("test invalid values", func(t *testing.T) {
+		t.Helper()
+
+		if err = db.InsertMany(ctx, testDB, "users", []int{1}); !errors.Is(err, db.ErrInsertValue) {
+			t.Errorf("got error %v, want %v", err, db.ErrInsertValue)
+		}
+
+		if err = db.InsertMany(ctx, testDB, "users", []insertUser{}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
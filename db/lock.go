@@ -0,0 +1,428 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLockTTL   = 30 * time.Second
+	defaultLockRetry = time.Second
+	defaultLockTable = "db_locks"
+	lockOwnerSize    = 16
+)
+
+var (
+	ErrLockHeld = errors.New("lock is held by another owner")
+	ErrLockLost = errors.New("lock was lost")
+	ErrLockTTL  = errors.New("lock TTL must be positive")
+)
+
+type (
+	// Lock is the lock held by the process until Unlock is called or the lock is lost.
+	Lock struct {
+		name    string
+		lost    chan struct{}
+		done    chan struct{}
+		wg      sync.WaitGroup
+		once    sync.Once
+		release func(ctx context.Context) error
+	}
+
+	lockOptions struct {
+		ttl   time.Duration
+		retry time.Duration
+		table string
+		lease bool
+	}
+
+	lockOptionFunc func(*lockOptions)
+
+	LockOption interface {
+		apply(*lockOptions)
+	}
+)
+
+func (f lockOptionFunc) apply(o *lockOptions) {
+	f(o)
+}
+
+// WithLockTTL sets the lifetime of the lease, the lease is renewed every third of the TTL
+// and is taken over by another owner when the holder stops renewing it. The TTL shorter
+// than 3ns fails acquiring with ErrLockTTL.
+func WithLockTTL(ttl time.Duration) LockOption {
+	return lockOptionFunc(func(o *lockOptions) {
+		o.ttl = ttl
+	})
+}
+
+// WithLockRetry sets the interval of acquire attempts made by Lock.
+func WithLockRetry(interval time.Duration) LockOption {
+	return lockOptionFunc(func(o *lockOptions) {
+		o.retry = interval
+	})
+}
+
+// WithLockTable sets the name of the lease table, it is created by the first lock of the DB
+// when it does not exist.
+func WithLockTable(table string) LockOption {
+	return lockOptionFunc(func(o *lockOptions) {
+		o.table = table
+	})
+}
+
+// WithLeaseLock uses the lease table on Postgres as well, e.g. behind the transaction pooler
+// where advisory locks are not bound to the client session.
+func WithLeaseLock() LockOption {
+	return lockOptionFunc(func(o *lockOptions) {
+		o.lease = true
+	})
+}
+
+// Lock blocks until the named lock is acquired or ctx is done. Postgres uses the session
+// advisory lock held by the dedicated connection, other drivers use the lease table
+// with the TTL renewed in the background.
+func (db *DB) Lock(ctx context.Context, name string, opts ...LockOption) (*Lock, error) {
+	o := newLockOptions(opts)
+
+	for {
+		lock, err := db.tryLock(ctx, name, o)
+		if !errors.Is(err, ErrLockHeld) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(o.retry):
+		}
+	}
+}
+
+// TryLock acquires the named lock or returns ErrLockHeld without waiting.
+func (db *DB) TryLock(ctx context.Context, name string, opts ...LockOption) (*Lock, error) {
+	return db.tryLock(ctx, name, newLockOptions(opts))
+}
+
+// Lead campaigns for the leadership and runs fn while it is held, the context of fn is canceled
+// when the leadership is lost and the campaign starts again. The leadership is released when fn
+// returns, Lead returns the error of fn or nil when fn finishes or ctx is done.
+func (db *DB) Lead(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...LockOption) error {
+	for {
+		lock, err := db.Lock(ctx, name, opts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+
+		go func() {
+			select {
+			case <-lock.Lost():
+				cancel()
+			case <-leaderCtx.Done():
+			}
+		}()
+
+		err = fn(leaderCtx)
+
+		cancel()
+
+		unlockErr := lock.Unlock(context.WithoutCancel(ctx))
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case lock.isLost():
+			continue
+		default:
+			return errors.Join(err, unlockErr)
+		}
+	}
+}
+
+// Name returns the name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Lost returns the channel closed when the lock can not be renewed anymore.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Unlock stops the renewal and releases the lock, repeated calls do nothing.
+func (l *Lock) Unlock(ctx context.Context) error {
+	var err error
+
+	l.once.Do(func() {
+		close(l.done)
+		l.wg.Wait()
+
+		err = l.release(ctx)
+	})
+
+	return err
+}
+
+func (l *Lock) isLost() bool {
+	select {
+	case <-l.lost:
+		return true
+	default:
+		return false
+	}
+}
+
+// keepAlive renews the lock until it is unlocked, the lock is lost when the renewal reports
+// ErrLockLost or fails until the last interval of the TTL, so the holder stops before
+// the lease expires and another owner takes it over.
+func (l *Lock) keepAlive(ttl time.Duration, renew func(ctx context.Context) error) {
+	defer l.wg.Done()
+
+	interval := ttl / 3
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	renewed := time.Now()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+
+		started := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := renew(ctx)
+
+		cancel()
+
+		switch {
+		case err == nil:
+			renewed = started
+		case errors.Is(err, ErrLockLost) || time.Since(renewed) >= ttl-interval:
+			close(l.lost)
+			return
+		}
+	}
+}
+
+func newLock(name string, ttl time.Duration, renew, release func(ctx context.Context) error) *Lock {
+	l := &Lock{
+		name:    name,
+		lost:    make(chan struct{}),
+		done:    make(chan struct{}),
+		release: release,
+	}
+
+	l.wg.Add(1)
+
+	go l.keepAlive(ttl, renew)
+
+	return l
+}
+
+func newLockOptions(opts []LockOption) *lockOptions {
+	o := &lockOptions{
+		ttl:   defaultLockTTL,
+		retry: defaultLockRetry,
+		table: defaultLockTable,
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+func (db *DB) tryLock(ctx context.Context, name string, o *lockOptions) (*Lock, error) {
+	// the lease is renewed every third of the TTL
+	if o.ttl/3 <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLockTTL, o.ttl)
+	}
+
+	if (db.driver == Postgres || db.driver == PGX) && !o.lease {
+		return db.advisoryLock(ctx, name, o)
+	}
+
+	return db.leaseLock(ctx, name, o)
+}
+
+// advisoryLock holds the session advisory lock on the dedicated connection,
+// the connection is pinged to detect the lost session.
+func (db *DB) advisoryLock(ctx context.Context, name string, o *lockOptions) (*Lock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := lockKey(name)
+
+	var locked bool
+
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if !locked {
+		_ = conn.Close()
+		return nil, ErrLockHeld
+	}
+
+	renew := func(ctx context.Context) error {
+		if err := conn.PingContext(ctx); err != nil {
+			return errors.Join(ErrLockLost, err)
+		}
+
+		return nil
+	}
+
+	release := func(ctx context.Context) error {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		return errors.Join(err, conn.Close())
+	}
+
+	return newLock(name, o.ttl, renew, release), nil
+}
+
+// leaseLock inserts the lease row or takes over the expired one. Queries run on the primary
+// outside of the transaction of the context, the expiration is compared with the clock of
+// the database, so the clock skew of instances does not break the lease.
+func (db *DB) leaseLock(ctx context.Context, name string, o *lockOptions) (*Lock, error) {
+	if err := db.createLockTable(ctx, o.table); err != nil {
+		return nil, err
+	}
+
+	owner, err := lockOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	exec := func(ctx context.Context, query string, args ...any) (int64, error) {
+		result, err := db.DB.ExecContext(ctx, Rebind(db.driver, query), args...)
+		if err != nil {
+			return 0, err
+		}
+
+		return result.RowsAffected()
+	}
+
+	now := nowMillis(db.driver)
+	ttl := o.ttl.Milliseconds()
+
+	affected, err := exec(ctx, "UPDATE "+o.table+" SET owner = ?, expires_at = "+now+" + ? WHERE name = ? AND expires_at < "+now,
+		owner, ttl, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		_, err = exec(ctx, "INSERT INTO "+o.table+" (name, owner, expires_at) VALUES (?, ?, "+now+" + ?)",
+			name, owner, ttl)
+		if err != nil {
+			var count int
+
+			query := Rebind(db.driver, "SELECT COUNT(*) FROM "+o.table+" WHERE name = ?")
+
+			if db.DB.QueryRowContext(ctx, query, name).Scan(&count) == nil && count > 0 {
+				return nil, ErrLockHeld
+			}
+
+			return nil, err
+		}
+	}
+
+	renew := func(ctx context.Context) error {
+		affected, err := exec(ctx, "UPDATE "+o.table+" SET expires_at = "+now+" + ? WHERE name = ? AND owner = ?",
+			ttl, name, owner)
+
+		switch {
+		case err != nil:
+			return err
+		case affected == 0:
+			return ErrLockLost
+		default:
+			return nil
+		}
+	}
+
+	release := func(ctx context.Context) error {
+		_, err := exec(ctx, "DELETE FROM "+o.table+" WHERE name = ? AND owner = ?", name, owner)
+		return err
+	}
+
+	return newLock(name, o.ttl, renew, release), nil
+}
+
+// createLockTable creates the lease table once per DB.
+func (db *DB) createLockTable(ctx context.Context, table string) error {
+	if _, ok := db.lockTables.Load(table); ok {
+		return nil
+	}
+
+	query := "CREATE TABLE IF NOT EXISTS " + table +
+		" (name VARCHAR(255) PRIMARY KEY, owner VARCHAR(64) NOT NULL, expires_at BIGINT NOT NULL)"
+
+	if db.driver == SQLServer {
+		query = "IF OBJECT_ID(N'" + table + "', N'U') IS NULL CREATE TABLE " + table +
+			" (name NVARCHAR(255) PRIMARY KEY, owner NVARCHAR(64) NOT NULL, expires_at BIGINT NOT NULL)"
+	}
+
+	if _, err := db.DB.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	db.lockTables.Store(table, struct{}{})
+
+	return nil
+}
+
+// nowMillis returns the expression of the current time of the database in Unix milliseconds.
+func nowMillis(drv DriverName) string {
+	//nolint:exhaustive // SQLite is the default
+	switch drv {
+	case Postgres, PGX:
+		return "CAST(EXTRACT(EPOCH FROM clock_timestamp()) * 1000 AS BIGINT)"
+	case MySQL:
+		return "CAST(UNIX_TIMESTAMP(NOW(3)) * 1000 AS SIGNED)"
+	case SQLServer:
+		return "DATEDIFF_BIG(MILLISECOND, '1970-01-01', SYSUTCDATETIME())"
+	case Clickhouse:
+		return "toUnixTimestamp64Milli(now64(3))"
+	default:
+		return "CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+	}
+}
+
+func lockOwner() (string, error) {
+	owner := make([]byte, lockOwnerSize)
+
+	if _, err := rand.Read(owner); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(owner), nil
+}
+
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	//nolint:gosec // overflow is expected for the advisory lock key
+	return int64(h.Sum64())
+}
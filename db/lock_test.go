@@ -0,0 +1,225 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+)
+
+func TestLock(t *testing.T) {
+	testDB := dbtest.New(t)
+	ctx := context.Background()
+
+	t.Run("test try lock", func(t *testing.T) {
+		t.Helper()
+
+		lock, err := testDB.TryLock(ctx, "jobs")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.TryLock(ctx, "jobs"); !errors.Is(err, db.ErrLockHeld) {
+			t.Errorf("got %v, want %v", err, db.ErrLockHeld)
+		}
+
+		other, err := testDB.TryLock(ctx, "reports")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = errors.Join(lock.Unlock(ctx), other.Unlock(ctx), lock.Unlock(ctx)); err != nil {
+			t.Fatal(err)
+		}
+
+		if lock, err = testDB.TryLock(ctx, "jobs"); err != nil {
+			t.Fatal(err)
+		}
+
+		_ = lock.Unlock(ctx)
+	})
+
+	t.Run("test invalid ttl", func(t *testing.T) {
+		t.Helper()
+
+		for _, ttl := range []time.Duration{-time.Second, 0, 2} {
+			if _, err := testDB.TryLock(ctx, "jobs", db.WithLockTTL(ttl)); !errors.Is(err, db.ErrLockTTL) {
+				t.Errorf("got %v, want %v", err, db.ErrLockTTL)
+			}
+
+			if _, err := testDB.Lock(ctx, "jobs", db.WithLockTTL(ttl)); !errors.Is(err, db.ErrLockTTL) {
+				t.Errorf("got %v, want %v", err, db.ErrLockTTL)
+			}
+		}
+	})
+
+	t.Run("test take over expired lease", func(t *testing.T) {
+		t.Helper()
+
+		_, err := testDB.Exec("INSERT INTO db_locks (name, owner, expires_at) VALUES ('expired', 'crashed', 0)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lock, err := testDB.TryLock(ctx, "expired")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = lock.Unlock(ctx)
+	})
+
+	t.Run("test wait for lock", func(t *testing.T) {
+		t.Helper()
+
+		lock, err := testDB.TryLock(ctx, "wait")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.AfterFunc(50*time.Millisecond, func() {
+			_ = lock.Unlock(ctx)
+		})
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		next, err := testDB.Lock(waitCtx, "wait", db.WithLockRetry(10*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.Lock(waitCtx, "wait", db.WithLockRetry(10*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		_ = next.Unlock(ctx)
+	})
+
+	t.Run("test lost lease", func(t *testing.T) {
+		t.Helper()
+
+		lock, err := testDB.TryLock(ctx, "lost", db.WithLockTTL(30*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = testDB.Exec("DELETE FROM db_locks WHERE name = 'lost'"); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-lock.Lost():
+		case <-time.After(time.Second):
+			t.Error("lock was not lost")
+		}
+
+		_ = lock.Unlock(ctx)
+	})
+
+	t.Run("test lost before expiration", func(t *testing.T) {
+		t.Helper()
+
+		ttl := 300 * time.Millisecond
+		start := time.Now()
+
+		lock, err := testDB.TryLock(ctx, "failing", db.WithLockTTL(ttl), db.WithLockTable("failing_locks"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// renewals fail without the table
+		if _, err = testDB.Exec("DROP TABLE failing_locks"); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-lock.Lost():
+			if elapsed := time.Since(start); elapsed >= ttl {
+				t.Errorf("lock was lost after %s, want before the lease expired after %s", elapsed, ttl)
+			}
+		case <-time.After(time.Second):
+			t.Error("lock was not lost")
+		}
+
+		_ = lock.Unlock(ctx)
+	})
+}
+
+func TestLead(t *testing.T) {
+	testDB := dbtest.New(t)
+	ctx := context.Background()
+	opts := []db.LockOption{db.WithLockTTL(30 * time.Millisecond), db.WithLockRetry(10 * time.Millisecond)}
+
+	t.Run("test run while leading", func(t *testing.T) {
+		t.Helper()
+
+		var calls atomic.Int32
+
+		errStop := errors.New("stop")
+
+		err := testDB.Lead(ctx, "leader", func(leaderCtx context.Context) error {
+			if calls.Add(1) == 1 {
+				// another instance takes over the leadership
+				_, _ = testDB.Exec("UPDATE db_locks SET owner = 'other' WHERE name = 'leader'")
+				<-leaderCtx.Done()
+
+				_, _ = testDB.Exec("DELETE FROM db_locks WHERE name = 'leader'")
+
+				return leaderCtx.Err()
+			}
+
+			return errStop
+		}, opts...)
+
+		if !errors.Is(err, errStop) || calls.Load() != 2 {
+			t.Errorf("got %v after %d calls, want %v after 2 calls", err, calls.Load(), errStop)
+		}
+	})
+
+	t.Run("test finished leadership", func(t *testing.T) {
+		t.Helper()
+
+		err := testDB.Lead(ctx, "leader", func(context.Context) error {
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Error(err)
+		}
+
+		lock, err := testDB.TryLock(ctx, "leader")
+		if err != nil {
+			t.Fatalf("leadership was not released: %v", err)
+		}
+
+		_ = lock.Unlock(ctx)
+	})
+
+	t.Run("test canceled campaign", func(t *testing.T) {
+		t.Helper()
+
+		lock, err := testDB.TryLock(ctx, "busy")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = lock.Unlock(ctx)
+		}()
+
+		campaignCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		err = testDB.Lead(campaignCtx, "busy", func(context.Context) error {
+			t.Error("callback must not be called without the leadership")
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type (
+	tagOptions []string
+
+	field struct {
+		name    string
+		index   []int
+		options tagOptions
+	}
+
+	structMap struct {
+		fields []*field
+		names  map[string]*field
+	}
+)
+
+//nolint:gochecknoglobals // used to cache compiled struct mappers
+var structMaps sync.Map
+
+func getStructMap(rt reflect.Type) *structMap {
+	if sm, ok := structMaps.Load(rt); ok {
+		return sm.(*structMap)
+	}
+
+	sm := &structMap{names: make(map[string]*field)}
+	sm.compile(rt, nil, "", nil)
+
+	actual, _ := structMaps.LoadOrStore(rt, sm)
+
+	return actual.(*structMap)
+}
+
+// compile maps fields of the struct and its nested structs, the struct type already
+// on the path is skipped, so self-referential models never loop.
+func (sm *structMap) compile(rt reflect.Type, index []int, prefix string, path []reflect.Type) {
+	path = append(path, rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+
+		name, options := parseTag(sf.Tag.Get(tagName))
+		if name == "-" || sf.Tag.Get(relationTagName) != "" {
+			continue
+		}
+
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if isStruct(ft) && !options.Has(tagOptionJSON) {
+			switch {
+			case slices.Contains(path, ft):
+			case sf.Anonymous && name == "":
+				sm.compile(ft, fieldIndex, prefix, path)
+			case name == "":
+				sm.compile(ft, fieldIndex, prefix+fieldMap(sf.Name)+"_", path)
+			default:
+				sm.compile(ft, fieldIndex, prefix+name+"_", path)
+			}
+
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = fieldMap(sf.Name)
+		}
+
+		sm.add(&field{name: prefix + name, index: fieldIndex, options: options})
+	}
+}
+
+func (sm *structMap) add(f *field) {
+	if exists, ok := sm.names[f.name]; ok {
+		if len(exists.index) <= len(f.index) {
+			return
+		}
+
+		for i, item := range sm.fields {
+			if item == exists {
+				sm.fields = append(sm.fields[:i], sm.fields[i+1:]...)
+				break
+			}
+		}
+	}
+
+	sm.names[f.name] = f
+	sm.fields = append(sm.fields, f)
+}
+
+// fieldByIndex returns the field by its index path allocating nil pointers on the way.
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(x)
+	}
+
+	return rv
+}
+
+// valueByIndex returns the field by its index path, the result is invalid when the path
+// contains a nil pointer.
+func valueByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(x)
+	}
+
+	return rv
+}
+
+func parseTag(tag string) (string, tagOptions) {
+	name, options, _ := strings.Cut(tag, ",")
+	if options == "" {
+		return name, nil
+	}
+
+	return name, strings.Split(options, ",")
+}
+
+func (o tagOptions) Has(option string) bool {
+	return slices.Contains(o, option)
+}
@@ -0,0 +1,137 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type (
+	mapperBase struct {
+		ID int
+	}
+
+	mapperAddress struct {
+		City   string
+		Street sql.NullString
+	}
+
+	mapperUser struct {
+		mapperBase
+		Name     *string
+		Address  mapperAddress  `db:"address"`
+		Billing  *mapperAddress `db:"billing"`
+		Nickname sql.NullString
+		Ignored  string `db:"-"`
+	}
+)
+
+func TestMapper(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	strictDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+		db.WithStrictScan(),
+	)
+
+	ctx := context.Background()
+
+	query := `SELECT 1 AS id, 'foo' AS name, 'Moscow' AS address_city, NULL AS address_street,
+		'Kazan' AS billing_city, 'Baumana' AS billing_street, NULL AS nickname`
+
+	t.Run("test nested and embedded structs", func(t *testing.T) {
+		t.Helper()
+
+		var user mapperUser
+
+		err := testDB.Get(ctx, &user, query)
+
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case user.ID != 1:
+			t.Errorf("got id %d, want 1", user.ID)
+		case user.Name == nil || *user.Name != "foo":
+			t.Errorf("got name %v, want foo", user.Name)
+		case user.Address.City != "Moscow" || user.Address.Street.Valid:
+			t.Errorf("got address %v, want Moscow without street", user.Address)
+		case user.Billing == nil || user.Billing.City != "Kazan" || user.Billing.Street.String != "Baumana":
+			t.Errorf("got billing %v, want Kazan Baumana", user.Billing)
+		case user.Nickname.Valid:
+			t.Errorf("got nickname %v, want null", user.Nickname)
+		}
+	})
+
+	t.Run("test named binding of nested structs", func(t *testing.T) {
+		t.Helper()
+
+		name := "foo"
+		user := mapperUser{mapperBase: mapperBase{ID: 1}, Name: &name, Address: mapperAddress{City: "Moscow"}}
+
+		query, args, err := db.BindNamed(db.SQLite, "SELECT :id, :name, :address_city, :billing_city", user)
+
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case query != "SELECT ?, ?, ?, ?":
+			t.Errorf("got query %q", query)
+		case args[0] != 1 || args[1] != &name || args[2] != "Moscow" || args[3] != nil:
+			t.Errorf("got args %v", args)
+		}
+	})
+
+	t.Run("test strict mode", func(t *testing.T) {
+		t.Helper()
+
+		var user mapperUser
+
+		if err := strictDB.Get(ctx, &user, query); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		err := strictDB.Get(ctx, &user, "SELECT 1 AS id, 'foo' AS unknown")
+		if !errors.Is(err, db.ErrUnmappedColumn) {
+			t.Errorf("got error %v, want %v", err, db.ErrUnmappedColumn)
+		}
+
+		if err = testDB.Get(ctx, &user, "SELECT 1 AS id, 'foo' AS unknown"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		for _, err = range db.Query[mapperUser](ctx, strictDB, "SELECT 'foo' AS unknown") {
+			if !errors.Is(err, db.ErrUnmappedColumn) {
+				t.Errorf("got error %v, want %v", err, db.ErrUnmappedColumn)
+			}
+		}
+	})
+}
+
+func TestMapperSelfReference(t *testing.T) {
+	type node struct {
+		ID     int64 `db:"id"`
+		Parent *node
+	}
+
+	testDB, err := db.New(db.WithConfigDSN("sqlite://:memory:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = testDB.Close()
+	})
+
+	var value node
+
+	if err = testDB.Get(context.Background(), &value, "SELECT 1 AS id"); err != nil {
+		t.Fatal(err)
+	} else if value.ID != 1 || value.Parent != nil {
+		t.Errorf("got %+v, want id 1 without parent", value)
+	}
+}
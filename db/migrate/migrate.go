@@ -0,0 +1,424 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+const defaultTable = "schema_migrations"
+
+var (
+	ErrInvalidFileName  = errors.New("invalid migration file name")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrMissingMigration = errors.New("applied migration is missing")
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	ErrNoDownMigration  = errors.New("migration has no down script")
+	ErrLocked           = errors.New("migrations are locked by another runner")
+
+	fileNameRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+type (
+	Migration struct {
+		Version  int64
+		Name     string
+		Up       string
+		Down     string
+		Checksum string
+	}
+
+	Status struct {
+		Migration
+		Applied   bool
+		AppliedAt time.Time
+		// Missing is set when the migration is applied, but its file is not found.
+		Missing bool
+		// Mismatch is set when the applied checksum differs from the file checksum.
+		Mismatch bool
+	}
+
+	Migrator struct {
+		db     *db.DB
+		fsys   fs.FS
+		table  string
+		dryRun bool
+	}
+
+	record struct {
+		Version   int64     `db:"version"`
+		Name      string    `db:"name"`
+		Checksum  string    `db:"checksum"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+)
+
+// New creates a migrator for the migrations stored in fsys as `<version>_<name>.up.sql`
+// and `<version>_<name>.down.sql` files, fsys is usually an embed.FS.
+func New(database *db.DB, fsys fs.FS, opts ...Option) *Migrator {
+	m := &Migrator{
+		db:    database,
+		fsys:  fsys,
+		table: defaultTable,
+	}
+
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	return m
+}
+
+// Load reads and parses all migrations sorted by version.
+func (m *Migrator) Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		matches := fileNameRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		content, err := fs.ReadFile(m.fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := index[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			index[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			migration.Checksum = checksum(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(index))
+
+	for _, migration := range index {
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Status returns the state of all known migrations including applied ones
+// whose files are missing or changed after they were applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return status(migrations, applied), nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.migrate(ctx, func(migrations []Migration, _ int64) (int64, error) {
+		if len(migrations) == 0 {
+			return 0, nil
+		}
+
+		return migrations[len(migrations)-1].Version, nil
+	})
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	return m.migrate(ctx, func(migrations []Migration, current int64) (int64, error) {
+		var target int64
+
+		for _, migration := range migrations {
+			if migration.Version >= current {
+				break
+			}
+
+			target = migration.Version
+		}
+
+		return target, nil
+	})
+}
+
+// Migrate applies or rolls back migrations until the target version is reached,
+// the zero target version rolls back all migrations.
+func (m *Migrator) Migrate(ctx context.Context, target int64) ([]Migration, error) {
+	return m.migrate(ctx, func(migrations []Migration, _ int64) (int64, error) {
+		if target == 0 {
+			return 0, nil
+		}
+
+		for _, migration := range migrations {
+			if migration.Version == target {
+				return target, nil
+			}
+		}
+
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	})
+}
+
+func (m *Migrator) migrate(
+	ctx context.Context,
+	target func(migrations []Migration, current int64) (int64, error),
+) ([]Migration, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	migrations, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verify(status(migrations, applied)); err != nil {
+		return nil, err
+	}
+
+	var current int64
+
+	for version := range applied {
+		current = max(current, version)
+	}
+
+	version, err := target(migrations, current)
+	if err != nil {
+		return nil, err
+	}
+
+	if version >= current {
+		return m.up(ctx, migrations, applied, version)
+	}
+
+	return m.down(ctx, migrations, applied, version)
+}
+
+func (m *Migrator) up(ctx context.Context, migrations []Migration, applied map[int64]record, target int64) ([]Migration, error) {
+	result := make([]Migration, 0)
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > target {
+			continue
+		}
+
+		if !m.dryRun {
+			err := m.db.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+
+				return db.Insert(ctx, tx, m.table, record{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now().UTC(),
+				})
+			})
+			if err != nil {
+				return result, fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		result = append(result, migration)
+	}
+
+	return result, nil
+}
+
+func (m *Migrator) down(ctx context.Context, migrations []Migration, applied map[int64]record, target int64) ([]Migration, error) {
+	result := make([]Migration, 0)
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= target {
+			continue
+		}
+
+		if migration.Down == "" {
+			return result, fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+		}
+
+		if !m.dryRun {
+			err := m.db.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.ExecNamed(ctx, "DELETE FROM "+m.table+" WHERE version = :version", record{
+					Version: migration.Version,
+				})
+
+				return err
+			})
+			if err != nil {
+				return result, fmt.Errorf("rollback migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		result = append(result, migration)
+	}
+
+	return result, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.table+` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]record, error) {
+	records := make([]record, 0)
+
+	if err := m.db.Select(ctx, &records, "SELECT version, name, checksum, applied_at FROM "+m.table); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]record, len(records))
+
+	for _, item := range records {
+		result[item.Version] = item
+	}
+
+	return result, nil
+}
+
+// lock prevents concurrent runners with the named lock of the DB: the advisory lock
+// on Postgres or the lease renewed while migrations run for the other drivers, so the lock
+// of the crashed runner expires with its lease.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	lock, err := m.db.TryLock(ctx, m.table)
+
+	switch {
+	case errors.Is(err, db.ErrLockHeld):
+		return nil, fmt.Errorf("%w: %w", ErrLocked, err)
+	case err != nil:
+		return nil, err
+	}
+
+	return func() {
+		_ = lock.Unlock(context.WithoutCancel(ctx))
+	}, nil
+}
+
+func status(migrations []Migration, applied map[int64]record) []Status {
+	result := make([]Status, 0, len(migrations))
+	known := make(map[int64]bool, len(migrations))
+
+	for _, migration := range migrations {
+		item := Status{Migration: migration}
+		known[migration.Version] = true
+
+		if rec, ok := applied[migration.Version]; ok {
+			item.Applied = true
+			item.AppliedAt = rec.AppliedAt
+			item.Mismatch = rec.Checksum != migration.Checksum
+		}
+
+		result = append(result, item)
+	}
+
+	for version, rec := range applied {
+		if known[version] {
+			continue
+		}
+
+		result = append(result, Status{
+			Migration: Migration{Version: rec.Version, Name: rec.Name, Checksum: rec.Checksum},
+			Applied:   true,
+			AppliedAt: rec.AppliedAt,
+			Missing:   true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result
+}
+
+func verify(statuses []Status) error {
+	var errs []error
+
+	for _, item := range statuses {
+		switch {
+		case item.Missing:
+			errs = append(errs, fmt.Errorf("%w: %d_%s", ErrMissingMigration, item.Version, item.Name))
+		case item.Mismatch:
+			errs = append(errs, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, item.Version, item.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kamilov/go-kit/db/dbtest"
+	"github.com/kamilov/go-kit/db/migrate"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newFS() fstest.MapFS {
+	return fstest.MapFS{
+		"1_create_users.up.sql":      {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")},
+		"1_create_users.down.sql":    {Data: []byte("DROP TABLE users")},
+		"2_create_orders.up.sql":     {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER)")},
+		"2_create_orders.down.sql":   {Data: []byte("DROP TABLE orders")},
+		"3_add_users_email.up.sql":   {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT")},
+		"3_add_users_email.down.sql": {Data: []byte("ALTER TABLE users DROP COLUMN email")},
+		"README.md":                  {Data: []byte("migrations")},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test dry run", func(t *testing.T) {
+		t.Helper()
+
+		testDB := dbtest.New(t)
+
+		applied, err := migrate.New(testDB, newFS(), migrate.WithDryRun()).Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(applied) != 3 {
+			t.Errorf("got %d planned migrations, want 3", len(applied))
+		}
+
+		var count int
+
+		_ = testDB.Get(ctx, &count, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'")
+		if count != 0 {
+			t.Error("dry run applied migrations")
+		}
+	})
+
+	t.Run("test up, target and down", func(t *testing.T) {
+		t.Helper()
+
+		testDB := dbtest.New(t)
+		migrator := migrate.New(testDB, newFS(), migrate.WithTable("migrations"))
+
+		applied, err := migrator.Migrate(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(applied) != 2 {
+			t.Fatalf("got %d applied migrations, want 2", len(applied))
+		}
+
+		applied, err = migrator.Up(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(applied) != 1 || applied[0].Version != 3 {
+			t.Fatalf("got %v applied migrations, want version 3", applied)
+		}
+
+		if _, err = testDB.Exec("INSERT INTO users (id, name, email) VALUES (1, 'foo', 'foo@example.com')"); err != nil {
+			t.Fatal(err)
+		}
+
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(reverted) != 1 || reverted[0].Version != 3 {
+			t.Fatalf("got %v reverted migrations, want version 3", reverted)
+		}
+
+		reverted, err = migrator.Migrate(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(reverted) != 2 || reverted[0].Version != 2 {
+			t.Fatalf("got %v reverted migrations, want versions 2 and 1", reverted)
+		}
+
+		if _, err = migrator.Migrate(ctx, 10); !errors.Is(err, migrate.ErrUnknownVersion) {
+			t.Errorf("got error %v, want %v", err, migrate.ErrUnknownVersion)
+		}
+	})
+
+	t.Run("test drift", func(t *testing.T) {
+		t.Helper()
+
+		testDB := dbtest.New(t)
+
+		if _, err := migrate.New(testDB, newFS()).Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		changed := newFS()
+		changed["2_create_orders.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE orders (id INTEGER)")}
+		delete(changed, "3_add_users_email.up.sql")
+		delete(changed, "3_add_users_email.down.sql")
+
+		migrator := migrate.New(testDB, changed)
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(statuses) != 3 || !statuses[1].Mismatch || !statuses[2].Missing {
+			t.Errorf("got %+v, want mismatch of 2 and missing 3", statuses)
+		}
+
+		_, err = migrator.Up(ctx)
+		if !errors.Is(err, migrate.ErrChecksumMismatch) || !errors.Is(err, migrate.ErrMissingMigration) {
+			t.Errorf("got error %v, want drift errors", err)
+		}
+	})
+
+	t.Run("test lock", func(t *testing.T) {
+		t.Helper()
+
+		testDB := dbtest.New(t)
+
+		lock, err := testDB.TryLock(ctx, "schema_migrations")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = migrate.New(testDB, newFS()).Up(ctx); !errors.Is(err, migrate.ErrLocked) {
+			t.Errorf("got error %v, want %v", err, migrate.ErrLocked)
+		}
+
+		if err = lock.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// the expired lease of the crashed runner is taken over
+		if _, err = testDB.Exec("INSERT INTO db_locks (name, owner, expires_at) VALUES ('schema_migrations', 'crashed', 0)"); err != nil {
+			t.Fatal(err)
+		}
+
+		if applied, err := migrate.New(testDB, newFS()).Up(ctx); err != nil || len(applied) != 3 {
+			t.Errorf("got %d migrations and %v, want 3", len(applied), err)
+		}
+	})
+
+	t.Run("test invalid file name", func(t *testing.T) {
+		t.Helper()
+
+		fsys := newFS()
+		fsys["create.sql"] = &fstest.MapFile{Data: []byte("SELECT 1")}
+
+		if _, err := migrate.New(dbtest.New(t), fsys).Load(); !errors.Is(err, migrate.ErrInvalidFileName) {
+			t.Errorf("got error %v, want %v", err, migrate.ErrInvalidFileName)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package migrate
+
+type (
+	optionFunc func(*Migrator)
+
+	Option interface {
+		apply(*Migrator)
+	}
+)
+
+func (f optionFunc) apply(m *Migrator) {
+	f(m)
+}
+
+// WithTable sets the name of the table used to track applied migrations.
+func WithTable(table string) Option {
+	return optionFunc(func(m *Migrator) {
+		m.table = table
+	})
+}
+
+// WithDryRun reports migrations which would be applied or rolled back without executing them,
+// only the tracking table is created when it does not exist yet.
+func WithDryRun() Option {
+	return optionFunc(func(m *Migrator) {
+		m.dryRun = true
+	})
+}
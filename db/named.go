@@ -0,0 +1,350 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNamedArgument   = errors.New("named argument must be a struct or a map with string keys")
+	ErrNamedNotFound   = errors.New("named parameter not found")
+	ErrNamedEmptySlice = errors.New("named parameter contains an empty slice")
+)
+
+func (db *DB) SelectNamed(ctx context.Context, data any, query string, arg any) error {
+	query, args, err := BindNamed(db.driver, query, arg)
+	if err != nil {
+		return err
+	}
+
+	return db.Select(ctx, data, query, args...)
+}
+
+func (db *DB) GetNamed(ctx context.Context, data any, query string, arg any) error {
+	query, args, err := BindNamed(db.driver, query, arg)
+	if err != nil {
+		return err
+	}
+
+	return db.Get(ctx, data, query, args...)
+}
+
+func (db *DB) ExecNamed(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return execNamed(ctx, db, query, arg)
+}
+
+// execNamed binds and runs the named statement, the write is recorded in the audit log.
+func execNamed(ctx context.Context, q Querier, query string, arg any) (sql.Result, error) {
+	auditTable := settingsOf(q).auditLog(ctx)
+	match := auditStatementRegex.FindStringSubmatch(query)
+
+	if auditTable == "" || match == nil {
+		bound, args, err := BindNamed(q.DriverName(), query, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return q.ExecContext(ctx, bound, args...)
+	}
+
+	var result sql.Result
+
+	err := audited(ctx, q, auditTable, func(ctx context.Context, q Querier) ([]*AuditEntry, error) {
+		lookup, err := namedLookup(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &AuditEntry{
+			Table:     match[2],
+			Operation: strings.ToUpper(strings.Fields(match[1])[0]),
+			Changes:   make(AuditChanges),
+		}
+
+		bound, args, err := bindNamed(q.DriverName(), query, func(name string) (any, bool) {
+			value, ok := lookup(name)
+			if ok {
+				entry.Changes[name] = AuditChange{New: namedAuditValue(arg, name)}
+			}
+
+			return value, ok
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if result, err = q.ExecContext(ctx, bound, args...); err != nil {
+			return nil, err
+		}
+
+		if change, ok := entry.Changes[defaultPrimaryKey]; ok {
+			entry.Key = fmt.Sprint(change.New)
+		}
+
+		return []*AuditEntry{entry}, nil
+	})
+
+	return result, err
+}
+
+// BindNamed replaces `:name` placeholders in query with the driver specific positional
+// placeholders and returns the list of arguments taken from the struct or map arg.
+// Slice values are expanded, so `IN (:ids)` becomes `IN (?, ?, ?)`.
+func BindNamed(drv DriverName, query string, arg any) (string, []any, error) {
+	lookup, err := namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return bindNamed(drv, query, lookup)
+}
+
+func bindNamed(drv DriverName, query string, lookup func(string) (any, bool)) (string, []any, error) {
+	var (
+		buf  strings.Builder
+		args []any
+	)
+
+	for i := 0; i < len(query); i++ {
+		if end := skipLiteral(query, i); end > i {
+			buf.WriteString(query[i:end])
+			i = end - 1
+
+			continue
+		}
+
+		c := query[i]
+
+		switch {
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			buf.WriteString("::")
+			i++
+
+		case c == ':' && i+1 < len(query) && isNameChar(query[i+1]):
+			end := i + 1
+			for end < len(query) && isNameChar(query[end]) {
+				end++
+			}
+
+			name := query[i+1 : end]
+
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("%w: %s", ErrNamedNotFound, name)
+			}
+
+			values, err := expandValue(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: %s", err, name)
+			}
+
+			for j := range values {
+				if j > 0 {
+					buf.WriteString(", ")
+				}
+
+				buf.WriteByte('?')
+			}
+
+			args = append(args, values...)
+			i = end - 1
+
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return Rebind(drv, buf.String()), args, nil
+}
+
+// Rebind converts `?` placeholders in query into the placeholder style of the driver:
+// `$N` for Postgres and `@pN` for SQL Server.
+func Rebind(drv DriverName, query string) string {
+	var prefix string
+
+	//nolint:exhaustive // other drivers use question placeholders
+	switch drv {
+	case PGX, Postgres:
+		prefix = "$"
+	case SQLServer:
+		prefix = "@p"
+	default:
+		return query
+	}
+
+	var (
+		buf strings.Builder
+		n   int
+	)
+
+	buf.Grow(len(query))
+
+	for i := 0; i < len(query); i++ {
+		if end := skipLiteral(query, i); end > i {
+			buf.WriteString(query[i:end])
+			i = end - 1
+
+			continue
+		}
+
+		c := query[i]
+
+		switch c {
+		case '?':
+			n++
+
+			buf.WriteString(prefix)
+			buf.WriteString(strconv.Itoa(n))
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String()
+}
+
+func namedLookup(arg any) (func(string) (any, bool), error) {
+	rv := reflect.Indirect(reflect.ValueOf(arg))
+
+	//nolint:exhaustive // process only struct and map
+	switch rv.Kind() {
+	case reflect.Struct:
+		sm := getStructMap(rv.Type())
+
+		return func(name string) (any, bool) {
+			f, ok := sm.names[name]
+			if !ok {
+				return nil, false
+			}
+
+			return f.arg(valueByIndex(rv, f.index)), true
+		}, nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, ErrNamedArgument
+		}
+
+		return func(name string) (any, bool) {
+			value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+
+			return argValue(value), true
+		}, nil
+
+	default:
+		return nil, ErrNamedArgument
+	}
+}
+
+// namedAuditValue returns the audited value of the named argument, encrypted fields are redacted.
+func namedAuditValue(arg any, name string) any {
+	rv := reflect.Indirect(reflect.ValueOf(arg))
+
+	if rv.Kind() == reflect.Struct {
+		f := getStructMap(rv.Type()).names[name]
+		return auditValue(f, valueByIndex(rv, f.index))
+	}
+
+	return auditValue(nil, rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())))
+}
+
+func expandValue(value any) ([]any, error) {
+	if _, ok := value.(driver.Valuer); ok {
+		return []any{value}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{value}, nil
+	}
+
+	if rv.Len() == 0 {
+		return nil, ErrNamedEmptySlice
+	}
+
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+
+	return values, nil
+}
+
+// skipLiteral returns the end of the quoted string, the comment or the dollar-quoted
+// string starting at start, so placeholders inside of them are kept as is. The start
+// is returned when no literal starts there.
+func skipLiteral(query string, start int) int {
+	switch c := query[start]; {
+	case c == '\'' || c == '"' || c == '`':
+		return skipQuoted(query, start)
+	case strings.HasPrefix(query[start:], "--"):
+		if end := strings.IndexByte(query[start:], '\n'); end >= 0 {
+			return start + end + 1
+		}
+
+		return len(query)
+	case strings.HasPrefix(query[start:], "/*"):
+		if end := strings.Index(query[start+2:], "*/"); end >= 0 {
+			return start + 2 + end + 2
+		}
+
+		return len(query)
+	case c == '$':
+		return skipDollarQuoted(query, start)
+	default:
+		return start
+	}
+}
+
+// skipDollarQuoted skips the Postgres `$$...$$` or `$tag$...$tag$` string, positional
+// placeholders like `$1` are not strings.
+func skipDollarQuoted(query string, start int) int {
+	end := start + 1
+	for end < len(query) && isNameChar(query[end]) {
+		end++
+	}
+
+	if end >= len(query) || query[end] != '$' || end > start+1 && query[start+1] >= '0' && query[start+1] <= '9' {
+		return start
+	}
+
+	tag := query[start : end+1]
+
+	if i := strings.Index(query[end+1:], tag); i >= 0 {
+		return end + 1 + i + len(tag)
+	}
+
+	return len(query)
+}
+
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+
+		return i + 1
+	}
+
+	return len(query)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
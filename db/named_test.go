@@ -0,0 +1,196 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestBindNamed(t *testing.T) {
+	type filter struct {
+		UserID int `db:"id"`
+		Name   string
+		IDs    []int  `db:"ids"`
+		Skip   string `db:"-"`
+	}
+
+	tests := []struct {
+		name   string
+		config db.Config
+		query  string
+		arg    any
+		want   string
+		args   []any
+		err    error
+	}{
+		{
+			"struct with question placeholders",
+			db.Config{Driver: db.SQLite},
+			"SELECT * FROM users WHERE id = :id AND name = :name",
+			filter{UserID: 1, Name: "foo"},
+			"SELECT * FROM users WHERE id = ? AND name = ?",
+			[]any{1, "foo"},
+			nil,
+		},
+		{
+			"struct pointer with dollar placeholders",
+			db.Config{Driver: db.PGX},
+			"SELECT * FROM users WHERE id = :id AND name = :name",
+			&filter{UserID: 1, Name: "foo"},
+			"SELECT * FROM users WHERE id = $1 AND name = $2",
+			[]any{1, "foo"},
+			nil,
+		},
+		{
+			"map with slice expansion",
+			db.Config{Driver: db.Postgres},
+			"SELECT * FROM users WHERE id IN (:ids) AND name = :name",
+			map[string]any{"ids": []int{1, 2, 3}, "name": "foo"},
+			"SELECT * FROM users WHERE id IN ($1, $2, $3) AND name = $4",
+			[]any{1, 2, 3, "foo"},
+			nil,
+		},
+		{
+			"casts and quoted strings are kept",
+			db.Config{Driver: db.Postgres},
+			"SELECT ':skip', id::text FROM users WHERE id = :id",
+			map[string]any{"id": 1},
+			"SELECT ':skip', id::text FROM users WHERE id = $1",
+			[]any{1},
+			nil,
+		},
+		{
+			"comments are kept",
+			db.Config{Driver: db.Postgres},
+			"SELECT id -- :skip ?\nFROM users /* :skip ? */ WHERE id = :id",
+			map[string]any{"id": 1},
+			"SELECT id -- :skip ?\nFROM users /* :skip ? */ WHERE id = $1",
+			[]any{1},
+			nil,
+		},
+		{
+			"dollar-quoted bodies are kept",
+			db.Config{Driver: db.Postgres},
+			"DO $body$ BEGIN PERFORM :skip; END $body$; SELECT $$:skip$$, :id",
+			map[string]any{"id": 1},
+			"DO $body$ BEGIN PERFORM :skip; END $body$; SELECT $$:skip$$, $1",
+			[]any{1},
+			nil,
+		},
+		{
+			"struct with sql server placeholders",
+			db.Config{Driver: db.SQLServer},
+			"SELECT * FROM users WHERE id = :id AND name = :name",
+			filter{UserID: 1, Name: "foo"},
+			"SELECT * FROM users WHERE id = @p1 AND name = @p2",
+			[]any{1, "foo"},
+			nil,
+		},
+		{
+			"bytes are not expanded",
+			db.Config{Driver: db.Clickhouse},
+			"INSERT INTO blobs (data) VALUES (:data)",
+			map[string]any{"data": []byte("foo")},
+			"INSERT INTO blobs (data) VALUES (?)",
+			[]any{[]byte("foo")},
+			nil,
+		},
+		{
+			"missing parameter",
+			db.Config{Driver: db.SQLite},
+			"SELECT * FROM users WHERE id = :skip",
+			filter{},
+			"",
+			nil,
+			db.ErrNamedNotFound,
+		},
+		{
+			"empty slice",
+			db.Config{Driver: db.SQLite},
+			"SELECT * FROM users WHERE id IN (:ids)",
+			filter{},
+			"",
+			nil,
+			db.ErrNamedEmptySlice,
+		},
+		{
+			"invalid argument",
+			db.Config{Driver: db.SQLite},
+			"SELECT * FROM users WHERE id = :id",
+			1,
+			"",
+			nil,
+			db.ErrNamedArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Helper()
+
+			query, args, err := db.BindNamed(test.config.Driver, test.query, test.arg)
+
+			switch {
+			case test.err != nil:
+				if !errors.Is(err, test.err) {
+					t.Fatalf("got error %v, want %v", err, test.err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case query != test.want:
+				t.Fatalf("got query %q, want %q", query, test.want)
+			case !reflect.DeepEqual(args, test.args):
+				t.Fatalf("got args %v, want %v", args, test.args)
+			}
+		})
+	}
+}
+
+func TestNamed(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	_, err := testDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	ctx := context.Background()
+
+	for i, name := range []string{"foo", "bar", "baz"} {
+		_, err = testDB.ExecNamed(ctx, "INSERT INTO users (id, name) VALUES (:id, :name)", user{i + 1, name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var users []user
+
+	err = testDB.SelectNamed(ctx, &users, "SELECT id, name FROM users WHERE id IN (:ids) ORDER BY id", map[string]any{
+		"ids": []int{1, 3},
+	})
+
+	switch {
+	case err != nil:
+		t.Error(err)
+	case len(users) != 2:
+		t.Errorf("got %d users, want 2", len(users))
+	case users[0].Name != "foo" || users[1].Name != "baz":
+		t.Errorf("got %v, want foo and baz", users)
+	}
+
+	_, err = testDB.ExecNamed(ctx, "DELETE FROM users WHERE id = :id", map[string]any{})
+	if !errors.Is(err, db.ErrNamedNotFound) {
+		t.Errorf("got error %v, want %v", err, db.ErrNamedNotFound)
+	}
+}
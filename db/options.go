@@ -1,16 +1,25 @@
 package db
 
 import (
-	"net/url"
-	"strings"
+	"time"
 
 	"github.com/loghole/dbhook"
 )
 
 type (
 	options struct {
-		config      *Config
-		hookOptions []dbhook.HookOption
+		configs             []*Config
+		errs                []error
+		hookOptions         []dbhook.HookOption
+		strict              bool
+		rowsObserver        RowsObserver
+		keyring             *Keyring
+		auditTable          string
+		balancer            Balancer
+		healthCheckInterval time.Duration
+		healthCheckTimeout  time.Duration
+		statementCacheSize  int
+		resultCacheSize     int
 	}
 
 	optionFunc func(*options)
@@ -24,37 +33,66 @@ func (f optionFunc) apply(o *options) {
 	f(o)
 }
 
+// WithConfig adds the connection configuration, it can be used several times
+// to add read replicas with the RoleReplica role next to the primary.
 func WithConfig(config *Config) Option {
 	return optionFunc(func(o *options) {
-		o.config = config
+		o.configs = append(o.configs, config)
 	})
 }
 
+// WithConfigDSN adds the connection configuration parsed by ParseDSN,
+// the parse error is returned by New.
 func WithConfigDSN(dsn string) Option {
-	u, _ := url.Parse(dsn)
-	config := &Config{
-		Hostname: u.Host,
-		Username: u.User.Username(),
-		Database: u.Path,
-		Params:   make(map[string]string),
-	}
+	return optionFunc(func(o *options) {
+		config, err := ParseDSN(dsn)
+		if err != nil {
+			o.errs = append(o.errs, err)
+			return
+		}
 
-	switch u.Scheme {
-	case "sqlite":
-		config.Driver = SQLite
-	default:
-		config.Driver = driverName(u.Scheme)
-	}
+		o.configs = append(o.configs, config)
+	})
+}
 
-	if password, set := u.User.Password(); set {
-		config.Password = password
-	}
+// WithStrictScan makes scanning into structs fail on columns without a matching field
+// instead of silently skipping them.
+func WithStrictScan() Option {
+	return optionFunc(func(o *options) {
+		o.strict = true
+	})
+}
 
-	for key, value := range u.Query() {
-		config.Params[key] = strings.Join(value, ",")
-	}
+// WithBalancer sets the strategy of choosing a replica for read queries.
+func WithBalancer(balancer Balancer) Option {
+	return optionFunc(func(o *options) {
+		o.balancer = balancer
+	})
+}
+
+// WithHealthCheck sets the interval and the timeout of the replica health checks,
+// the timeout is used by DB.Health as well.
+func WithHealthCheck(interval, timeout time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.healthCheckInterval = interval
+		o.healthCheckTimeout = timeout
+	})
+}
 
-	return WithConfig(config)
+// WithStatementCache keeps up to size prepared statements of the queries made by the DB outside
+// of transactions for every connection pool, statements outdated by schema changes are
+// prepared again, see DB.ResetStatements.
+func WithStatementCache(size int) Option {
+	return optionFunc(func(o *options) {
+		o.statementCacheSize = size
+	})
+}
+
+// WithResultCache keeps up to size results of the queries made with the context of WithCache.
+func WithResultCache(size int) Option {
+	return optionFunc(func(o *options) {
+		o.resultCacheSize = size
+	})
 }
 
 func WithHook(hooks ...dbhook.Hook) Option {
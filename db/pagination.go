@@ -0,0 +1,219 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const defaultPageLimit = 20
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is the part of the list with the cursor of the next part,
+// the empty cursor means the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EncodeCursor packs the values into the opaque URL safe cursor.
+func EncodeCursor(values ...any) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor unpacks the cursor created by EncodeCursor into the pointers,
+// the number of pointers must match the number of encoded values.
+func DecodeCursor(cursor string, values ...any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var items []json.RawMessage
+
+	if err = json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	if len(items) != len(values) {
+		return fmt.Errorf("%w: got %d values, want %d", ErrInvalidCursor, len(items), len(values))
+	}
+
+	for i, item := range items {
+		dec := json.NewDecoder(bytes.NewReader(item))
+		dec.UseNumber()
+
+		if err = dec.Decode(values[i]); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+	}
+
+	return nil
+}
+
+// KeysetPage returns the page of rows following the cursor sorted by WithOrderBy columns
+// and the primary key. Rows are selected by comparing the sort columns with the values
+// of the last row of the previous page, so the sort columns must not contain NULL.
+// The limit and the offset of opts are replaced by the page limit.
+func (r *Repository[T, ID]) KeysetPage(ctx context.Context, cursor string, limit int, opts ...ListOption) (*Page[T], error) {
+	o := newListOptions(opts)
+
+	orders, err := parseOrder(r.names, o.orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(orders, func(item order) bool { return item.field == r.pk }) {
+		orders = append(orders, order{field: r.pk})
+	}
+
+	if cursor != "" {
+		condition, args, err := r.keysetCondition(cursor, orders)
+		if err != nil {
+			return nil, err
+		}
+
+		o.where = append(o.where, condition)
+		o.args = append(o.args, args...)
+	}
+
+	o.orderBy = make([]string, len(orders))
+
+	for i, item := range orders {
+		o.orderBy[i] = item.field.name
+
+		if item.desc {
+			o.orderBy[i] += " DESC"
+		}
+	}
+
+	limit = pageLimit(limit)
+	o.limit = limit + 1
+	o.offset = 0
+
+	items, err := r.list(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+
+		last := reflect.ValueOf(&page.Items[limit-1]).Elem()
+		values := make([]any, len(orders))
+
+		for i, item := range orders {
+			values[i] = valueByIndex(last, item.field.index).Interface()
+		}
+
+		if page.NextCursor, err = EncodeCursor(values...); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// OffsetPage returns the page of rows skipping the number of rows stored in the cursor.
+// The limit and the offset of opts are replaced by the page limit.
+func (r *Repository[T, ID]) OffsetPage(ctx context.Context, cursor string, limit int, opts ...ListOption) (*Page[T], error) {
+	var offset int
+
+	if cursor != "" {
+		if err := DecodeCursor(cursor, &offset); err != nil {
+			return nil, err
+		}
+
+		if offset < 0 {
+			return nil, fmt.Errorf("%w: negative offset", ErrInvalidCursor)
+		}
+	}
+
+	o := newListOptions(opts)
+	limit = pageLimit(limit)
+	o.limit = limit + 1
+	o.offset = offset
+
+	items, err := r.list(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+
+		if page.NextCursor, err = EncodeCursor(offset + limit); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// keysetCondition selects rows after the cursor values, e.g. for `a, b DESC`
+// it is `(a > ? OR (a = ? AND b < ?))`.
+func (r *Repository[T, ID]) keysetCondition(cursor string, orders []order) (string, []any, error) {
+	rt := reflect.TypeFor[T]()
+	values := make([]any, len(orders))
+
+	for i, item := range orders {
+		values[i] = reflect.New(rt.FieldByIndex(item.field.index).Type).Interface()
+	}
+
+	if err := DecodeCursor(cursor, values...); err != nil {
+		return "", nil, err
+	}
+
+	var (
+		parts []string
+		args  []any
+	)
+
+	for i, item := range orders {
+		var buf strings.Builder
+
+		for j := range i {
+			buf.WriteString(orders[j].field.name)
+			buf.WriteString(" = ? AND ")
+
+			args = append(args, orders[j].field.arg(reflect.ValueOf(values[j]).Elem()))
+		}
+
+		buf.WriteString(item.field.name)
+
+		if item.desc {
+			buf.WriteString(" < ?")
+		} else {
+			buf.WriteString(" > ?")
+		}
+
+		args = append(args, item.field.arg(reflect.ValueOf(values[i]).Elem()))
+		parts = append(parts, "("+buf.String()+")")
+	}
+
+	return "(" + strings.Join(parts, " OR ") + ")", args, nil
+}
+
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageLimit
+	}
+
+	return limit
+}
@@ -0,0 +1,127 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCursor(t *testing.T) {
+	cursor, err := db.EncodeCursor("foo", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		name string
+		id   int64
+	)
+
+	if err = db.DecodeCursor(cursor, &name, &id); err != nil {
+		t.Fatal(err)
+	} else if name != "foo" || id != 10 {
+		t.Errorf("got %q and %d, want foo and 10", name, id)
+	}
+
+	if err = db.DecodeCursor(cursor, &name); !errors.Is(err, db.ErrInvalidCursor) {
+		t.Errorf("got error %v, want %v", err, db.ErrInvalidCursor)
+	}
+
+	if err = db.DecodeCursor("not a cursor", &name); !errors.Is(err, db.ErrInvalidCursor) {
+		t.Errorf("got error %v, want %v", err, db.ErrInvalidCursor)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	testDB := newRepositoryDB(t)
+
+	users, err := db.NewRepository[repositoryUser, int64](testDB, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// names repeat to check the primary key tie-breaker of the keyset
+	for _, name := range []string{"a", "b", "b", "b", "c", "d", "e"} {
+		if err = users.Create(ctx, &repositoryUser{Name: name, Status: "active"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = users.Delete(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(
+		t *testing.T,
+		page func(cursor string) (*db.Page[repositoryUser], error),
+	) []int64 {
+		t.Helper()
+
+		var (
+			ids    []int64
+			cursor string
+		)
+
+		for range 10 {
+			result, err := page(cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, item := range result.Items {
+				ids = append(ids, item.ID)
+			}
+
+			if cursor = result.NextCursor; cursor == "" {
+				return ids
+			}
+		}
+
+		t.Fatal("pagination does not stop")
+
+		return nil
+	}
+
+	t.Run("test keyset", func(t *testing.T) {
+		t.Helper()
+
+		ids := collect(t, func(cursor string) (*db.Page[repositoryUser], error) {
+			return users.KeysetPage(ctx, cursor, 2, db.WithOrderBy("name DESC"))
+		})
+
+		if want := []int64{6, 5, 2, 3, 4, 1}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("got %v, want %v", ids, want)
+		}
+	})
+
+	t.Run("test offset", func(t *testing.T) {
+		t.Helper()
+
+		ids := collect(t, func(cursor string) (*db.Page[repositoryUser], error) {
+			return users.OffsetPage(ctx, cursor, 4, db.WithFilter("status", "active"), db.WithOrderBy("id"))
+		})
+
+		if want := []int64{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("got %v, want %v", ids, want)
+		}
+	})
+
+	t.Run("test invalid cursor", func(t *testing.T) {
+		t.Helper()
+
+		cursor, _ := db.EncodeCursor(1)
+
+		if _, err := users.KeysetPage(ctx, cursor, 2, db.WithOrderBy("name")); !errors.Is(err, db.ErrInvalidCursor) {
+			t.Errorf("got error %v, want %v", err, db.ErrInvalidCursor)
+		}
+
+		if _, err := users.OffsetPage(ctx, "foo", 2); !errors.Is(err, db.ErrInvalidCursor) {
+			t.Errorf("got error %v, want %v", err, db.ErrInvalidCursor)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is implemented by both *DB and *Tx, so repository code
+// can run the same way in and out of a transaction.
+type Querier interface {
+	DriverName() DriverName
+
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+
+	Select(ctx context.Context, data any, query string, args ...any) error
+	Get(ctx context.Context, data any, query string, args ...any) error
+
+	SelectNamed(ctx context.Context, data any, query string, arg any) error
+	GetNamed(ctx context.Context, data any, query string, arg any) error
+	ExecNamed(ctx context.Context, query string, arg any) (sql.Result, error)
+}
+
+type settings struct {
+	driver       DriverName
+	strict       bool
+	rowsObserver RowsObserver
+	keyring      *Keyring
+	auditTable   string
+}
+
+var (
+	_ Querier = (*DB)(nil)
+	_ Querier = (*Tx)(nil)
+)
+
+// DriverName returns the name of the driver used by the connection.
+func (s settings) DriverName() DriverName {
+	return s.driver
+}
+
+func (s settings) querySettings() settings {
+	return s
+}
+
+// settingsOf returns the settings of the kit querier or the defaults for foreign implementations.
+func settingsOf(q Querier) settings {
+	if impl, ok := q.(interface{ querySettings() settings }); ok {
+		return impl.querySettings()
+	}
+
+	return settings{}
+}
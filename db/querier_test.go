@@ -0,0 +1,110 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type querierUser struct {
+	ID   int
+	Name string
+}
+
+func createQuerierUser(ctx context.Context, q db.Querier, user querierUser) error {
+	_, err := q.ExecNamed(ctx, "INSERT INTO users (id, name) VALUES (:id, :name)", user)
+	return err
+}
+
+func findQuerierUser(ctx context.Context, q db.Querier, id int) (querierUser, error) {
+	var user querierUser
+
+	err := q.Get(ctx, &user, "SELECT id, name FROM users WHERE id = ?", id)
+
+	return user, err
+}
+
+func TestQuerier(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("test db querier", func(t *testing.T) {
+		t.Helper()
+
+		if err = createQuerierUser(ctx, testDB, querierUser{1, "foo"}); err != nil {
+			t.Fatal(err)
+		}
+
+		user, err := findQuerierUser(ctx, testDB, 1)
+		if err != nil {
+			t.Fatal(err)
+		} else if user.Name != "foo" {
+			t.Errorf("got %q, want %q", user.Name, "foo")
+		}
+
+		var count int
+
+		if err = testDB.Get(ctx, &count, "SELECT COUNT(*) FROM users"); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("got %d, want 1", count)
+		}
+
+		if _, err = findQuerierUser(ctx, testDB, 2); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+
+	t.Run("test tx querier", func(t *testing.T) {
+		t.Helper()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			if err := createQuerierUser(ctx, tx, querierUser{2, "bar"}); err != nil {
+				return err
+			}
+
+			var users []querierUser
+
+			if err := tx.SelectNamed(ctx, &users, "SELECT id, name FROM users WHERE id IN (:ids)", map[string]any{
+				"ids": []int{1, 2},
+			}); err != nil {
+				return err
+			}
+
+			if len(users) != 2 {
+				t.Errorf("got %d users, want 2", len(users))
+			}
+
+			var user querierUser
+
+			if err := tx.GetNamed(ctx, &user, "SELECT id, name FROM users WHERE id = :id", user); err != nil {
+				if !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+			}
+
+			return errors.New("rollback")
+		})
+		if err == nil {
+			t.Fatal("transactional did not return an error")
+		}
+
+		if _, err = findQuerierUser(ctx, testDB, 2); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"iter"
+	"reflect"
+)
+
+// Query executes the query and lazily scans every row into T. The rows are
+// closed when the iteration is finished or stopped by the caller.
+//
+//	for user, err := range db.Query[User](ctx, database, "SELECT * FROM users") {
+//		if err != nil {
+//			return err
+//		}
+//	}
+func Query[T any](ctx context.Context, q Querier, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		if err := ctx.Err(); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		s := settingsOf(q)
+
+		scan, err := newRowScanner(ctx, rows, reflect.TypeFor[T](), s)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		var count int64
+
+		defer func() {
+			s.observeRows(ctx, query, count)
+		}()
+
+		for rows.Next() {
+			var value T
+
+			if err = scan(rows, reflect.ValueOf(&value).Elem()); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			count++
+
+			if !yield(value, nil) {
+				return
+			}
+		}
+
+		if err = rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestQuery(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 10; i++ {
+		_, _ = testDB.Exec("INSERT INTO foo (id, name) VALUES (?, ?)", i, "foo")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("test query struct", func(t *testing.T) {
+		t.Helper()
+
+		type row struct {
+			ID   int
+			Name string
+		}
+
+		var count int
+
+		for value, err := range db.Query[row](ctx, testDB, "SELECT id, name FROM foo ORDER BY id") {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			count++
+
+			if value.ID != count || value.Name != "foo" {
+				t.Errorf("got %v, want {%d foo}", value, count)
+			}
+		}
+
+		if count != 10 {
+			t.Errorf("got %d rows, want 10", count)
+		}
+	})
+
+	t.Run("test query scalar with break", func(t *testing.T) {
+		t.Helper()
+
+		for value, err := range db.Query[int](ctx, testDB, "SELECT id FROM foo ORDER BY id") {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if value == 3 {
+				break
+			}
+		}
+
+		var count int
+
+		if err = testDB.Get(ctx, &count, "SELECT COUNT(*) FROM foo"); err != nil {
+			t.Errorf("rows are not closed after break: %v", err)
+		} else if count != 10 {
+			t.Errorf("got %d, want 10", count)
+		}
+	})
+
+	t.Run("test query error", func(t *testing.T) {
+		t.Helper()
+
+		var errs int
+
+		for _, err := range db.Query[int](ctx, testDB, "SELECT id FROM bar") {
+			if err != nil {
+				errs++
+			}
+		}
+
+		if errs != 1 {
+			t.Errorf("got %d errors, want 1", errs)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cronSearchYears = 5
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+//nolint:gochecknoglobals // used as constants
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron is the parsed cron expression, fields are bit sets of allowed values.
+type Cron struct {
+	every   time.Duration
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	anyDay  bool
+	anyWeek bool
+}
+
+// ParseCron parses the standard five fields expression `minute hour day month weekday`
+// with lists, ranges and steps, the descriptors like `@daily` and `@every 5m`. Expressions
+// which never match, e.g. February 30, are rejected.
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+
+	if value, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCron, spec)
+		}
+
+		return &Cron{every: every}, nil
+	}
+
+	if descriptor, ok := cronDescriptors[spec]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCron, spec)
+	}
+
+	s := &Cron{anyDay: fields[2] == "*", anyWeek: fields[4] == "*"}
+	targets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+	for i, field := range fields {
+		bits, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCron, spec, err)
+		}
+
+		*targets[i] = bits
+	}
+
+	// both 0 and 7 are Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: %s: never matches", ErrInvalidCron, spec)
+	}
+
+	return s, nil
+}
+
+func parseCronField(field string, low, high int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		value, stepValue, hasStep := strings.Cut(part, "/")
+		start, end, step := low, high, 1
+
+		if hasStep {
+			var err error
+
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		if value != "*" {
+			from, to, isRange := strings.Cut(value, "-")
+
+			var err error
+
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+
+			switch {
+			case isRange:
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			case !hasStep:
+				end = start
+			}
+		}
+
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("value %q is out of range %d-%d", part, low, high)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << i
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time after t matching the schedule, the zero time is returned
+// when the expression never matches, e.g. for February 30.
+func (s *Cron) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+
+		switch {
+		case s.month&(1<<int(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchDay matches either the day of month or the weekday when both are restricted.
+func (s *Cron) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+
+	switch {
+	case s.anyDay:
+		return dow
+	case s.anyWeek:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,65 @@
+package queue_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db/queue"
+)
+
+func TestCron(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2024, time.January, 31, 10, 8, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC)},
+		{"list and range", "0 9-11,14 * * *", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"next month", "30 6 1 * *", time.Date(2024, time.February, 1, 6, 30, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"weekday", "0 8 * * 1-5", time.Date(2024, time.February, 1, 8, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"day or weekday", "0 0 15 * 5", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"descriptor", "@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"every", "@every 90s", from.Add(90 * time.Second)},
+	}
+
+	for _, test := range tests {
+		t.Run("test "+test.name, func(t *testing.T) {
+			t.Helper()
+
+			cron, err := queue.ParseCron(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := cron.Next(from); !got.Equal(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("test never", func(t *testing.T) {
+		t.Helper()
+
+		for _, spec := range []string{"0 0 31 2 *", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+			if _, err := queue.ParseCron(spec); !errors.Is(err, queue.ErrInvalidCron) {
+				t.Errorf("%q: got %v, want %v", spec, err, queue.ErrInvalidCron)
+			}
+		}
+	})
+
+	t.Run("test invalid", func(t *testing.T) {
+		t.Helper()
+
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every -1s", "@every x"} {
+			if _, err := queue.ParseCron(spec); !errors.Is(err, queue.ErrInvalidCron) {
+				t.Errorf("%q: got %v, want %v", spec, err, queue.ErrInvalidCron)
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultTable        = "jobs"
+	defaultConcurrency  = 10
+	defaultPollInterval = time.Second
+	defaultLeaseTTL     = 5 * time.Minute
+	defaultMaxAttempts  = 10
+	defaultBaseDelay    = time.Second
+	defaultMaxDelay     = time.Hour
+	maxErrorDelay       = time.Minute
+	maxBackoffShift     = 20
+)
+
+type (
+	options struct {
+		table        string
+		concurrency  int
+		pollInterval time.Duration
+		leaseTTL     time.Duration
+		backoff      func(attempt int) time.Duration
+		errorHandler func(ctx context.Context, err error)
+	}
+
+	optionFunc func(*options)
+
+	Option interface {
+		apply(*options)
+	}
+
+	enqueueOptions struct {
+		table       string
+		runAt       time.Time
+		uniqueKey   *string
+		maxAttempts int
+	}
+
+	enqueueOptionFunc func(*enqueueOptions)
+
+	EnqueueOption interface {
+		apply(*enqueueOptions)
+	}
+)
+
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+func (f enqueueOptionFunc) apply(o *enqueueOptions) {
+	f(o)
+}
+
+// WithTable sets the name of the jobs table.
+func WithTable(table string) Option {
+	return optionFunc(func(o *options) {
+		o.table = table
+	})
+}
+
+// WithConcurrency limits the number of jobs claimed and handled at once,
+// the non-positive value keeps the default.
+func WithConcurrency(concurrency int) Option {
+	return optionFunc(func(o *options) {
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	})
+}
+
+// WithPollInterval sets the delay between polls when the queue is empty,
+// the non-positive interval keeps the default.
+func WithPollInterval(interval time.Duration) Option {
+	return optionFunc(func(o *options) {
+		if interval > 0 {
+			o.pollInterval = interval
+		}
+	})
+}
+
+// WithLeaseTTL sets how long the claimed job is reserved for the worker, the lease is renewed
+// every third of the TTL while the handler runs and jobs of crashed workers are claimed again
+// after the lease expires. The non-positive TTL keeps the default.
+func WithLeaseTTL(ttl time.Duration) Option {
+	return optionFunc(func(o *options) {
+		if ttl > 0 {
+			o.leaseTTL = ttl
+		}
+	})
+}
+
+// WithErrorHandler sets the handler of the queue errors reported by Run, e.g. the failed claim
+// or the lost database connection, by default errors are logged with slog.
+func WithErrorHandler(handler func(ctx context.Context, err error)) Option {
+	return optionFunc(func(o *options) {
+		o.errorHandler = handler
+	})
+}
+
+// WithBackoff sets the delay before the next attempt of the failed job, by default
+// the delay grows exponentially from 1s up to 1h.
+func WithBackoff(backoff func(attempt int) time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.backoff = backoff
+	})
+}
+
+// WithEnqueueTable sets the name of the jobs table.
+func WithEnqueueTable(table string) EnqueueOption {
+	return enqueueOptionFunc(func(o *enqueueOptions) {
+		o.table = table
+	})
+}
+
+// WithRunAt schedules the job to run at the given time.
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return enqueueOptionFunc(func(o *enqueueOptions) {
+		o.runAt = runAt
+	})
+}
+
+// WithDelay schedules the job to run after the delay.
+func WithDelay(delay time.Duration) EnqueueOption {
+	return enqueueOptionFunc(func(o *enqueueOptions) {
+		o.runAt = time.Now().Add(delay)
+	})
+}
+
+// WithUniqueKey skips the job when the job with the same key is waiting or running.
+func WithUniqueKey(key string) EnqueueOption {
+	return enqueueOptionFunc(func(o *enqueueOptions) {
+		o.uniqueKey = &key
+	})
+}
+
+// WithMaxAttempts sets the number of attempts before the job is marked as failed.
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return enqueueOptionFunc(func(o *enqueueOptions) {
+		o.maxAttempts = attempts
+	})
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		table:        defaultTable,
+		concurrency:  defaultConcurrency,
+		pollInterval: defaultPollInterval,
+		leaseTTL:     defaultLeaseTTL,
+		backoff:      exponentialBackoff,
+		errorHandler: logError,
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+func newEnqueueOptions(opts []EnqueueOption) *enqueueOptions {
+	o := &enqueueOptions{
+		table:       defaultTable,
+		runAt:       time.Now(),
+		maxAttempts: defaultMaxAttempts,
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+func logError(ctx context.Context, err error) {
+	slog.ErrorContext(ctx, "queue worker failed", slog.String("error", err.Error()))
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	delay := defaultBaseDelay << min(max(attempt-1, 0), maxBackoffShift)
+
+	return min(delay, defaultMaxDelay)
+}
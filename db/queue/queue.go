@@ -0,0 +1,154 @@
+// Package queue implements the persistent job queue stored in the database table. Jobs are
+// enqueued in the transaction of the business changes, so they are committed or rolled back
+// together, and are handled by workers running endpoint.Command handlers:
+//
+//	err := database.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+//		// ... create the user
+//		return queue.Enqueue(ctx, tx, "welcome_email", WelcomeEmail{UserID: user.ID})
+//	})
+//
+//	worker := queue.NewWorker(database)
+//	queue.Register[WelcomeEmail](worker, "welcome_email", sendWelcomeEmail)
+//
+//	err = worker.Run(ctx)
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusFailed  = "failed"
+)
+
+var ErrUnsupportedDriver = errors.New("job queue is not supported by the driver")
+
+type (
+	// Job describes the job handled by the worker.
+	Job struct {
+		ID          int64
+		Kind        string
+		Attempt     int
+		MaxAttempts int
+	}
+
+	row struct {
+		ID          int64   `db:"id,auto"`
+		Kind        string  `db:"kind"`
+		Payload     string  `db:"payload"`
+		UniqueKey   *string `db:"unique_key"`
+		Cron        *string `db:"cron"`
+		Status      string  `db:"status"`
+		Attempts    int     `db:"attempts"`
+		MaxAttempts int     `db:"max_attempts"`
+		RunAt       int64   `db:"run_at"`
+		LockedBy    *string `db:"locked_by"`
+		LockedUntil *int64  `db:"locked_until"`
+		LastError   *string `db:"last_error"`
+		CreatedAt   int64   `db:"created_at"`
+	}
+
+	jobContextKey struct{}
+)
+
+// JobFromContext returns the job handled with the context, e.g. to make the handler
+// idempotent or to log the attempt.
+func JobFromContext(ctx context.Context) (Job, bool) {
+	job, ok := ctx.Value(jobContextKey{}).(Job)
+	return job, ok
+}
+
+// Schema returns the statements creating the jobs table, e.g. to add them to a migration.
+func Schema(drv db.DriverName, table string) ([]string, error) {
+	var id, text string
+
+	//nolint:exhaustive // only these drivers are supported
+	switch drv {
+	case db.Postgres, db.PGX:
+		id, text = "BIGSERIAL PRIMARY KEY", "TEXT"
+	case db.SQLite:
+		id, text = "INTEGER PRIMARY KEY AUTOINCREMENT", "TEXT"
+	case db.MySQL:
+		id, text = "BIGINT AUTO_INCREMENT PRIMARY KEY", "LONGTEXT"
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, drv)
+	}
+
+	columns := `(
+	id ` + id + `,
+	kind VARCHAR(255) NOT NULL,
+	payload ` + text + ` NOT NULL,
+	unique_key VARCHAR(255) UNIQUE,
+	cron VARCHAR(255),
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL,
+	max_attempts INT NOT NULL,
+	run_at BIGINT NOT NULL,
+	locked_by VARCHAR(64),
+	locked_until BIGINT,
+	last_error ` + text + `,
+	created_at BIGINT NOT NULL`
+
+	if drv == db.MySQL {
+		return []string{"CREATE TABLE IF NOT EXISTS " + table + " " + columns + ",\n\tINDEX (status, run_at)\n)"}, nil
+	}
+
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " " + columns + "\n)",
+		"CREATE INDEX IF NOT EXISTS " + table + "_status_run_at ON " + table + " (status, run_at)",
+	}, nil
+}
+
+// Install creates the jobs table when it does not exist.
+func Install(ctx context.Context, q db.Querier, opts ...Option) error {
+	statements, err := Schema(q.DriverName(), newOptions(opts).table)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err = q.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Enqueue adds the job of the kind with the payload encoded as JSON, q is usually
+// the transaction changing the data the job is about. The job with the unique key
+// is skipped when the job with the same key is waiting or running.
+func Enqueue[T any](ctx context.Context, q db.Querier, kind string, payload T, opts ...EnqueueOption) error {
+	o := newEnqueueOptions(opts)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return insert(ctx, q, o.table, &row{
+		Kind:        kind,
+		Payload:     string(data),
+		UniqueKey:   o.uniqueKey,
+		Status:      StatusPending,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       o.runAt.UnixMilli(),
+		CreatedAt:   time.Now().UnixMilli(),
+	})
+}
+
+func insert(ctx context.Context, q db.Querier, table string, job *row) error {
+	if job.UniqueKey == nil {
+		return db.Insert(ctx, q, table, job)
+	}
+
+	return db.Insert(ctx, q, table, job, db.WithConflictIgnore("unique_key"))
+}
@@ -0,0 +1,477 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+	"github.com/kamilov/go-kit/db/queue"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type (
+	email struct {
+		To string `json:"to"`
+	}
+
+	commandFunc[T any] func(ctx context.Context, input T) error
+)
+
+func (f commandFunc[T]) Handle(ctx context.Context, input T) error {
+	return f(ctx, input)
+}
+
+func newQueueDB(t *testing.T) *db.DB {
+	t.Helper()
+
+	testDB := dbtest.New(t)
+
+	if err := queue.Install(context.Background(), testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	return testDB
+}
+
+func count(t *testing.T, testDB *db.DB, where string) int {
+	t.Helper()
+
+	var n int
+
+	if err := testDB.QueryRow("SELECT COUNT(*) FROM jobs WHERE " + where).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func work(t *testing.T, worker *queue.Worker, want int) {
+	t.Helper()
+
+	n, err := worker.Work(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != want {
+		t.Fatalf("got %d handled jobs, want %d", n, want)
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	testDB := newQueueDB(t)
+	ctx := context.Background()
+
+	t.Run("test transactional", func(t *testing.T) {
+		t.Helper()
+
+		err := testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			return queue.Enqueue(ctx, tx, "email", email{To: "committed"})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errRollback := errors.New("rollback")
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			if err := queue.Enqueue(ctx, tx, "email", email{To: "rolled back"}); err != nil {
+				return err
+			}
+
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("got %v, want %v", err, errRollback)
+		}
+
+		if n := count(t, testDB, "kind = 'email'"); n != 1 {
+			t.Errorf("got %d jobs, want 1", n)
+		}
+	})
+
+	t.Run("test unique key", func(t *testing.T) {
+		t.Helper()
+
+		for range 3 {
+			if err := queue.Enqueue(ctx, testDB, "report", email{}, queue.WithUniqueKey("daily")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n := count(t, testDB, "kind = 'report'"); n != 1 {
+			t.Errorf("got %d jobs, want 1", n)
+		}
+	})
+
+	t.Run("test unsupported driver", func(t *testing.T) {
+		t.Helper()
+
+		if _, err := queue.Schema(db.SQLServer, "jobs"); !errors.Is(err, queue.ErrUnsupportedDriver) {
+			t.Errorf("got %v, want %v", err, queue.ErrUnsupportedDriver)
+		}
+	})
+}
+
+func TestWorker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("test handle", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB)
+
+		var got []string
+
+		queue.Register[email](worker, "email", commandFunc[email](func(ctx context.Context, input email) error {
+			job, ok := queue.JobFromContext(ctx)
+			if !ok || job.Kind != "email" || job.Attempt != 1 {
+				t.Errorf("unexpected job %+v", job)
+			}
+
+			got = append(got, input.To)
+
+			return nil
+		}))
+
+		if err := queue.Enqueue(ctx, testDB, "email", email{To: "user@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := queue.Enqueue(ctx, testDB, "unknown", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+		work(t, worker, 0)
+
+		if len(got) != 1 || got[0] != "user@example.com" {
+			t.Errorf("got %v, want [user@example.com]", got)
+		}
+
+		if n := count(t, testDB, "kind = 'email'"); n != 0 {
+			t.Errorf("got %d finished jobs, want 0", n)
+		}
+	})
+
+	t.Run("test retry and fail", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB, queue.WithBackoff(func(int) time.Duration { return 0 }))
+
+		queue.Register[email](worker, "email", commandFunc[email](func(ctx context.Context, _ email) error {
+			if job, _ := queue.JobFromContext(ctx); job.Attempt == 2 {
+				panic("broken")
+			}
+
+			return errors.New("unavailable")
+		}))
+
+		err := queue.Enqueue(ctx, testDB, "email", email{}, queue.WithMaxAttempts(3), queue.WithUniqueKey("once"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+
+		if n := count(t, testDB, "status = 'pending' AND attempts = 1 AND last_error = 'unavailable'"); n != 1 {
+			t.Fatal("failed job was not rescheduled")
+		}
+
+		work(t, worker, 1)
+
+		if n := count(t, testDB, "status = 'pending' AND last_error = 'panic: broken'"); n != 1 {
+			t.Fatal("panic was not stored")
+		}
+
+		work(t, worker, 1)
+		work(t, worker, 0)
+
+		if n := count(t, testDB, "status = 'failed' AND attempts = 3 AND unique_key IS NULL"); n != 1 {
+			t.Fatal("job was not marked as failed")
+		}
+
+		if err = queue.Enqueue(ctx, testDB, "email", email{}, queue.WithUniqueKey("once")); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := count(t, testDB, "status = 'pending'"); n != 1 {
+			t.Errorf("got %d pending jobs, want 1", n)
+		}
+	})
+
+	t.Run("test delayed", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB)
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			return nil
+		}))
+
+		if err := queue.Enqueue(ctx, testDB, "email", email{}, queue.WithDelay(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 0)
+	})
+
+	t.Run("test expired lease", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB)
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			return nil
+		}))
+
+		if err := queue.Enqueue(ctx, testDB, "email", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := testDB.Exec("UPDATE jobs SET status = 'running', locked_by = 'crashed', locked_until = 0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+
+		if n := count(t, testDB, "1 = 1"); n != 0 {
+			t.Errorf("got %d jobs, want 0", n)
+		}
+	})
+
+	t.Run("test renewed lease", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB, queue.WithLeaseTTL(60*time.Millisecond))
+		other := queue.NewWorker(testDB)
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			time.Sleep(200 * time.Millisecond)
+			work(t, other, 0)
+
+			return nil
+		}))
+
+		queue.Register[email](other, "email", commandFunc[email](func(context.Context, email) error {
+			t.Error("job must not be claimed while the lease is renewed")
+			return nil
+		}))
+
+		if err := queue.Enqueue(ctx, testDB, "email", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+
+		if n := count(t, testDB, "1 = 1"); n != 0 {
+			t.Errorf("got %d jobs, want 0", n)
+		}
+	})
+
+	t.Run("test cron", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB)
+
+		var calls atomic.Int32
+
+		queue.Register[email](worker, "digest", commandFunc[email](func(context.Context, email) error {
+			calls.Add(1)
+			return nil
+		}))
+
+		if err := queue.Schedule(worker, "digest", "@every 1h", email{To: "team"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := queue.Schedule(worker, "digest", "not cron", email{}); !errors.Is(err, queue.ErrInvalidCron) {
+			t.Errorf("got %v, want %v", err, queue.ErrInvalidCron)
+		}
+
+		work(t, worker, 0)
+
+		if _, err := testDB.Exec("UPDATE jobs SET run_at = 0"); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+
+		minRunAt := time.Now().Add(59 * time.Minute).UnixMilli()
+
+		var runAt int64
+
+		err := testDB.QueryRow("SELECT run_at FROM jobs WHERE kind = 'digest' AND status = 'pending' AND attempts = 0").
+			Scan(&runAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if runAt < minRunAt || calls.Load() != 1 {
+			t.Errorf("got run at %d after %d calls, want after %d and 1 call", runAt, calls.Load(), minRunAt)
+		}
+
+		other := queue.NewWorker(testDB)
+
+		if err = queue.Schedule(other, "digest", "@daily", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, other, 0)
+
+		if n := count(t, testDB, "kind = 'digest' AND cron = '@daily'"); n != 1 {
+			t.Errorf("got %d updated schedules, want 1", n)
+		}
+	})
+
+	t.Run("test cron never matches", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB)
+
+		queue.Register[email](worker, "digest", commandFunc[email](func(context.Context, email) error {
+			return nil
+		}))
+
+		if err := queue.Schedule(worker, "digest", "0 0 30 2 *", email{}); !errors.Is(err, queue.ErrInvalidCron) {
+			t.Errorf("got %v, want %v", err, queue.ErrInvalidCron)
+		}
+
+		if err := queue.Enqueue(ctx, testDB, "digest", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the expression stored by an older worker
+		if _, err := testDB.Exec("UPDATE jobs SET cron = '0 0 30 2 *'"); err != nil {
+			t.Fatal(err)
+		}
+
+		work(t, worker, 1)
+		work(t, worker, 0)
+
+		if n := count(t, testDB, "status = 'failed' AND last_error LIKE '%never matches%'"); n != 1 {
+			t.Errorf("got %d failed jobs, want 1", n)
+		}
+	})
+
+	t.Run("test non-positive options", func(t *testing.T) {
+		t.Helper()
+
+		for _, concurrency := range []int{0, -1} {
+			testDB := newQueueDB(t)
+			worker := queue.NewWorker(testDB, queue.WithConcurrency(concurrency))
+
+			queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+				return nil
+			}))
+
+			if err := queue.Enqueue(ctx, testDB, "email", email{}); err != nil {
+				t.Fatal(err)
+			}
+
+			work(t, worker, 1)
+		}
+
+		runCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+
+		var failures atomic.Int32
+
+		// the default interval of a second leaves no time for the retry
+		worker := queue.NewWorker(newQueueDB(t), queue.WithTable("missing"), queue.WithPollInterval(0),
+			queue.WithErrorHandler(func(context.Context, error) {
+				failures.Add(1)
+			}))
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			return nil
+		}))
+
+		if err := worker.Run(runCtx); err != nil || failures.Load() != 1 {
+			t.Errorf("got %v after %d failures, want nil after 1 failure", err, failures.Load())
+		}
+	})
+
+	t.Run("test run", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		worker := queue.NewWorker(testDB, queue.WithPollInterval(10*time.Millisecond))
+		runCtx, cancel := context.WithCancel(ctx)
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			cancel()
+			return nil
+		}))
+
+		if err := queue.Enqueue(ctx, testDB, "email", email{}); err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan error, 1)
+
+		go func() {
+			done <- worker.Run(runCtx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("worker was not stopped")
+		}
+
+		if n := count(t, testDB, "1 = 1"); n != 0 {
+			t.Errorf("got %d jobs, want 0", n)
+		}
+	})
+
+	t.Run("test run after errors", func(t *testing.T) {
+		t.Helper()
+
+		testDB := newQueueDB(t)
+		runCtx, cancel := context.WithCancel(ctx)
+
+		var failures atomic.Int32
+
+		worker := queue.NewWorker(testDB, queue.WithTable("missing"), queue.WithPollInterval(time.Millisecond),
+			queue.WithErrorHandler(func(context.Context, error) {
+				if failures.Add(1) == 3 {
+					cancel()
+				}
+			}))
+
+		queue.Register[email](worker, "email", commandFunc[email](func(context.Context, email) error {
+			return nil
+		}))
+
+		done := make(chan error, 1)
+
+		go func() {
+			done <- worker.Run(runCtx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil || failures.Load() != 3 {
+				t.Errorf("got %v after %d failures, want nil after 3 failures", err, failures.Load())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("worker was not stopped")
+		}
+	})
+}
@@ -0,0 +1,396 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/endpoint"
+)
+
+const (
+	workerIDSize = 8
+	cronPrefix   = "cron:"
+)
+
+type (
+	// Worker claims due jobs of the registered kinds and runs their handlers. Handlers and
+	// schedules must be registered before the worker is started.
+	Worker struct {
+		db        *db.DB
+		id        string
+		options   *options
+		handlers  map[string]handler
+		schedules []schedule
+		scheduled bool
+	}
+
+	handler func(ctx context.Context, payload []byte) error
+
+	schedule struct {
+		kind    string
+		spec    string
+		cron    *Cron
+		payload []byte
+	}
+)
+
+func NewWorker(database *db.DB, opts ...Option) *Worker {
+	id := make([]byte, workerIDSize)
+	_, _ = rand.Read(id)
+
+	return &Worker{
+		db:       database,
+		id:       hex.EncodeToString(id),
+		options:  newOptions(opts),
+		handlers: make(map[string]handler),
+	}
+}
+
+// Register registers the handler of the jobs of the kind, payloads are decoded into T.
+func Register[T any](w *Worker, kind string, command endpoint.Command[T]) {
+	w.handlers[kind] = func(ctx context.Context, payload []byte) error {
+		var input T
+
+		if err := json.Unmarshal(payload, &input); err != nil {
+			return err
+		}
+
+		return command.Handle(ctx, input)
+	}
+}
+
+// Schedule registers the recurring job of the kind by the cron expression, see ParseCron.
+// The single job of the kind is shared by all workers and is rescheduled after every run.
+func Schedule[T any](w *Worker, kind, spec string, payload T) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	w.schedules = append(w.schedules, schedule{kind: kind, spec: spec, cron: cron, payload: data})
+
+	return nil
+}
+
+// Run handles jobs until the context is canceled. Errors of the queue are passed to the error
+// handler (see WithErrorHandler) and the worker retries with the delay growing from the poll
+// interval up to a minute.
+func (w *Worker) Run(ctx context.Context) error {
+	var failures int
+
+	for {
+		count, err := w.Work(ctx)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			w.options.errorHandler(ctx, err)
+			failures++
+		case count > 0:
+			failures = 0
+			continue
+		default:
+			failures = 0
+		}
+
+		delay := w.options.pollInterval
+		if failures > 0 {
+			delay = min(delay<<min(failures-1, maxBackoffShift), max(delay, maxErrorDelay))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Work claims due jobs and waits for their handlers, it returns the number of handled jobs.
+// Handler errors are stored in the job and retried with the backoff.
+func (w *Worker) Work(ctx context.Context) (int, error) {
+	if !w.scheduled {
+		if err := w.schedule(ctx); err != nil {
+			return 0, err
+		}
+
+		w.scheduled = true
+	}
+
+	jobs, err := w.claim(ctx)
+	if err != nil || len(jobs) == 0 {
+		return 0, err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  []error
+	)
+
+	for _, job := range jobs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := w.handle(ctx, job); err != nil {
+				mutex.Lock()
+				errs = append(errs, err)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return len(jobs), errors.Join(errs...)
+}
+
+// schedule creates the jobs of the schedules, the expression of the existing job is updated.
+func (w *Worker) schedule(ctx context.Context) error {
+	for _, item := range w.schedules {
+		key := cronPrefix + item.kind
+		spec := item.spec
+		runAt := item.cron.Next(time.Now()).UnixMilli()
+
+		err := insert(ctx, w.db, w.options.table, &row{
+			Kind:        item.kind,
+			Payload:     string(item.payload),
+			UniqueKey:   &key,
+			Cron:        &spec,
+			Status:      StatusPending,
+			MaxAttempts: defaultMaxAttempts,
+			RunAt:       runAt,
+			CreatedAt:   time.Now().UnixMilli(),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.db.ExecNamed(ctx, "UPDATE "+w.options.table+
+			" SET cron = :cron, payload = :payload, run_at = :run_at WHERE unique_key = :key AND cron <> :cron",
+			map[string]any{"cron": spec, "payload": string(item.payload), "run_at": runAt, "key": key})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claim reserves due jobs and expired leases of crashed workers in the transaction,
+// concurrent workers skip rows locked by each other on Postgres and MySQL, on other drivers
+// the conditional update lets only one worker claim the job.
+func (w *Worker) claim(ctx context.Context) ([]row, error) {
+	if len(w.handlers) == 0 {
+		return nil, nil
+	}
+
+	kinds := make([]string, 0, len(w.handlers))
+
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	now := time.Now().UnixMilli()
+	args := map[string]any{
+		"kinds":        kinds,
+		"now":          now,
+		"pending":      StatusPending,
+		"running":      StatusRunning,
+		"worker":       w.id,
+		"locked_until": time.Now().Add(w.options.leaseTTL).UnixMilli(),
+	}
+
+	table := w.options.table
+	claimable := "kind IN (:kinds) AND (status = :pending AND run_at <= :now OR status = :running AND locked_until < :now)"
+	query := "SELECT id FROM " + table + " WHERE " + claimable + " ORDER BY run_at, id LIMIT " +
+		strconv.Itoa(w.options.concurrency)
+
+	//nolint:exhaustive // only these drivers support SKIP LOCKED
+	switch w.db.DriverName() {
+	case db.Postgres, db.PGX, db.MySQL:
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+
+	var jobs []row
+
+	err := w.db.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+		var ids []int64
+
+		if err := tx.SelectNamed(ctx, &ids, query, args); err != nil || len(ids) == 0 {
+			return err
+		}
+
+		args["ids"] = ids
+
+		_, err := tx.ExecNamed(ctx, "UPDATE "+table+
+			" SET status = :running, locked_by = :worker, locked_until = :locked_until, attempts = attempts + 1"+
+			" WHERE id IN (:ids) AND "+claimable, args)
+		if err != nil {
+			return err
+		}
+
+		return tx.SelectNamed(ctx, &jobs, "SELECT * FROM "+table+
+			" WHERE id IN (:ids) AND locked_by = :worker AND locked_until = :locked_until ORDER BY run_at, id", args)
+	})
+
+	return jobs, err
+}
+
+func (w *Worker) handle(ctx context.Context, job row) error {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobCtx = context.WithValue(jobCtx, jobContextKey{}, Job{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Attempt:     job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+	})
+
+	stop := w.heartbeat(ctx, job, cancel)
+	err := call(jobCtx, w.handlers[job.Kind], []byte(job.Payload))
+
+	stop()
+
+	// the result is stored even when the worker is stopped
+	return w.complete(context.WithoutCancel(ctx), job, err)
+}
+
+// heartbeat renews the lease of the job every third of the lease TTL until stop is called,
+// so long handlers are not claimed by other workers. The handler is canceled when the lease
+// is lost, e.g. the renewal failed for the whole TTL and another worker claimed the job.
+func (w *Worker) heartbeat(ctx context.Context, job row, cancel context.CancelFunc) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(max(w.options.leaseTTL/3, time.Millisecond))
+		defer ticker.Stop()
+
+		args := map[string]any{"id": job.ID, "worker": w.id, "running": StatusRunning}
+		query := "UPDATE " + w.options.table + " SET locked_until = :locked_until" +
+			" WHERE id = :id AND locked_by = :worker AND status = :running"
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			args["locked_until"] = time.Now().Add(w.options.leaseTTL).UnixMilli()
+
+			result, err := w.db.ExecNamed(ctx, query, args)
+			if err != nil {
+				w.options.errorHandler(ctx, err)
+				continue
+			}
+
+			if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// complete removes the finished job, reschedules the recurring or failed job
+// and marks the job as failed after the last attempt.
+func (w *Worker) complete(ctx context.Context, job row, err error) error {
+	args := map[string]any{
+		"id":         job.ID,
+		"worker":     w.id,
+		"pending":    StatusPending,
+		"failed":     StatusFailed,
+		"last_error": nil,
+	}
+
+	if err != nil {
+		args["last_error"] = err.Error()
+	}
+
+	var (
+		query  string
+		failed = "UPDATE " + w.options.table + " SET status = :failed, unique_key = NULL, locked_by = NULL," +
+			" locked_until = NULL, last_error = :last_error WHERE id = :id AND locked_by = :worker"
+	)
+
+	switch {
+	case err == nil && job.Cron == nil:
+		query = "DELETE FROM " + w.options.table + " WHERE id = :id AND locked_by = :worker"
+
+	case err != nil && job.Attempts < job.MaxAttempts:
+		args["run_at"] = time.Now().Add(w.options.backoff(job.Attempts)).UnixMilli()
+		query = "UPDATE " + w.options.table + " SET status = :pending, run_at = :run_at, locked_by = NULL," +
+			" locked_until = NULL, last_error = :last_error WHERE id = :id AND locked_by = :worker"
+
+	case job.Cron != nil:
+		// the expression which never matches fails the job instead of running it in a loop
+		cron, cronErr := ParseCron(*job.Cron)
+		if cronErr != nil {
+			args["last_error"] = cronErr.Error()
+			query = failed
+
+			break
+		}
+
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			args["last_error"] = fmt.Sprintf("%s: %s: never matches", ErrInvalidCron, *job.Cron)
+			query = failed
+
+			break
+		}
+
+		args["run_at"] = next.UnixMilli()
+		query = "UPDATE " + w.options.table + " SET status = :pending, run_at = :run_at, attempts = 0, locked_by = NULL," +
+			" locked_until = NULL, last_error = :last_error WHERE id = :id AND locked_by = :worker"
+
+	default:
+		query = failed
+	}
+
+	_, err = w.db.ExecNamed(ctx, query, args)
+
+	return err
+}
+
+// call runs the handler converting the panic into the error of the job.
+func call(ctx context.Context, h handler, payload []byte) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return h(ctx, payload)
+}
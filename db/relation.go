@@ -0,0 +1,357 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const relationTagName = "relation"
+
+var (
+	ErrRelation        = errors.New("invalid relation")
+	ErrRelationParents = errors.New("relation parents must be a struct or a slice of structs")
+)
+
+type relation struct {
+	index   []int
+	table   string
+	target  reflect.Type
+	many    bool
+	local   *field
+	remote  *field
+	deleted *field
+	columns []*field
+	names   map[string]*field
+}
+
+// LoadRelation loads rows of the relation field for all parents with one `IN` query over
+// the collected parent keys instead of the query per parent. The field is tagged with
+// the related table and the columns matching parent and related rows:
+//
+//	type Order struct {
+//		ID    int64       `db:"id"`
+//		Items []OrderItem `relation:"order_items,id=order_id"`
+//	}
+//
+//	type OrderItem struct {
+//		ID      int64  `db:"id"`
+//		OrderID int64  `db:"order_id"`
+//		Order   *Order `relation:"orders,order_id=id"`
+//	}
+//
+// Slice fields are one-to-many relations, struct and pointer fields are many-to-one relations.
+// Nested relations are loaded by the dotted path, e.g. `Items.Order`, the options filter and sort
+// rows of the last relation of the path, soft deleted rows are skipped unless WithDeleted is used.
+func LoadRelation(ctx context.Context, q Querier, parents any, path string, opts ...ListOption) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	values, err := relationParents(reflect.ValueOf(parents))
+	if err != nil {
+		return err
+	}
+
+	names := strings.Split(path, ".")
+
+	for i, name := range names {
+		if len(values) == 0 {
+			return nil
+		}
+
+		o := &listOptions{}
+		if i == len(names)-1 {
+			o = newListOptions(opts)
+		}
+
+		if values, err = loadRelation(ctx, q, values, name, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadRelation(ctx context.Context, q Querier, parents []reflect.Value, name string, o *listOptions) ([]reflect.Value, error) {
+	rel, err := getRelation(parents[0].Type(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]any, 0, len(parents))
+	seen := make(map[string]struct{}, len(parents))
+
+	for _, parent := range parents {
+		value := valueByIndex(parent, rel.local.index)
+
+		key, ok := relationKey(value)
+		if !ok {
+			continue
+		}
+
+		if _, ok = seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, rel.local.arg(value))
+		}
+	}
+
+	children, err := rel.selectRows(ctx, q, keys, o)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]reflect.Value, len(keys))
+
+	for i := 0; i < children.Len(); i++ {
+		child := children.Index(i)
+
+		if key, ok := relationKey(valueByIndex(child, rel.remote.index)); ok {
+			groups[key] = append(groups[key], child)
+		}
+	}
+
+	var next []reflect.Value
+
+	visited := make(map[uintptr]struct{})
+
+	for _, parent := range parents {
+		var items []reflect.Value
+
+		if key, ok := relationKey(valueByIndex(parent, rel.local.index)); ok {
+			items = groups[key]
+		}
+
+		for _, item := range rel.set(fieldByIndex(parent, rel.index), items) {
+			// many-to-one relations share the same related row between parents
+			if _, ok := visited[item.Addr().Pointer()]; !ok {
+				visited[item.Addr().Pointer()] = struct{}{}
+				next = append(next, item)
+			}
+		}
+	}
+
+	return next, nil
+}
+
+func getRelation(rt reflect.Type, name string) (*relation, error) {
+	sf, ok := rt.FieldByName(name)
+	if !ok || sf.Tag.Get(relationTagName) == "" {
+		return nil, fmt.Errorf("%w: %s has no relation field %s", ErrRelation, rt, name)
+	}
+
+	table, columns, _ := strings.Cut(sf.Tag.Get(relationTagName), ",")
+	local, remote, ok := strings.Cut(columns, "=")
+
+	if table == "" || !ok {
+		return nil, fmt.Errorf("%w: %s.%s tag must be `table,column=related_column`", ErrRelation, rt, name)
+	}
+
+	rel := &relation{index: sf.Index, table: table, target: sf.Type}
+
+	if rel.target.Kind() == reflect.Slice {
+		rel.many = true
+		rel.target = rel.target.Elem()
+	}
+
+	if rel.target.Kind() == reflect.Pointer {
+		rel.target = rel.target.Elem()
+	}
+
+	if !isStruct(rel.target) {
+		return nil, fmt.Errorf("%w: %s.%s must be a struct or a slice of structs", ErrRelation, rt, name)
+	}
+
+	sm := getStructMap(rel.target)
+	rel.columns = sm.fields
+	rel.names = sm.names
+
+	if rel.local, ok = getStructMap(rt).names[local]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, local)
+	}
+
+	if rel.remote, ok = sm.names[remote]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, remote)
+	}
+
+	for _, f := range sm.fields {
+		if f.options.Has(tagOptionDeleted) {
+			rel.deleted = f
+		}
+	}
+
+	return rel, nil
+}
+
+// selectRows selects related rows matching the keys, the keys are split into several
+// queries when they exceed the parameter limit of the driver.
+func (rel *relation) selectRows(ctx context.Context, q Querier, keys []any, o *listOptions) (reflect.Value, error) {
+	result := reflect.New(reflect.SliceOf(rel.target)).Elem()
+
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	ctx = WithEncryptedTable(ctx, rel.table)
+	o.keyring, o.table = settingsOf(q).keyring, rel.table
+
+	var where, orderBy strings.Builder
+
+	args, err := writeWhere(&where, rel.names, rel.deleted, o)
+	if err != nil {
+		return result, err
+	}
+
+	orders, err := parseOrder(rel.names, o.orderBy)
+	if err != nil {
+		return result, err
+	}
+
+	for i, item := range orders {
+		if i == 0 {
+			orderBy.WriteString(" ORDER BY ")
+		} else {
+			orderBy.WriteString(", ")
+		}
+
+		orderBy.WriteString(item.field.name)
+
+		if item.desc {
+			orderBy.WriteString(" DESC")
+		}
+	}
+
+	drv := q.DriverName()
+	size := max(maxParams(drv)-len(args), 1)
+
+	for start := 0; start < len(keys); start += size {
+		batch := keys[start:min(start+size, len(keys))]
+
+		var buf strings.Builder
+
+		buf.WriteString("SELECT ")
+		buf.WriteString(columnNames(rel.columns))
+		buf.WriteString(" FROM ")
+		buf.WriteString(rel.table)
+		buf.WriteString(" WHERE ")
+		buf.WriteString(rel.remote.name)
+		buf.WriteString(" IN (")
+		buf.WriteString(strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", "))
+		buf.WriteString(")")
+
+		if where.Len() > 0 {
+			buf.WriteString(" AND (")
+			buf.WriteString(strings.TrimPrefix(where.String(), " WHERE "))
+			buf.WriteString(")")
+		}
+
+		buf.WriteString(orderBy.String())
+
+		rows := reflect.New(result.Type())
+
+		if err = q.Select(ctx, rows.Interface(), Rebind(drv, buf.String()), append(slices.Clone(batch), args...)...); err != nil {
+			return result, err
+		}
+
+		result = reflect.AppendSlice(result, rows.Elem())
+	}
+
+	return result, nil
+}
+
+// set stores the related rows into the parent field and returns the stored rows.
+func (rel *relation) set(dst reflect.Value, items []reflect.Value) []reflect.Value {
+	if rel.many {
+		result := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		stored := make([]reflect.Value, len(items))
+
+		for i, item := range items {
+			if result.Index(i).Kind() == reflect.Pointer {
+				result.Index(i).Set(item.Addr())
+			} else {
+				result.Index(i).Set(item)
+			}
+
+			stored[i] = reflect.Indirect(result.Index(i))
+		}
+
+		dst.Set(result)
+
+		return stored
+	}
+
+	if len(items) == 0 {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		dst.Set(items[0].Addr())
+	} else {
+		dst.Set(items[0])
+	}
+
+	return []reflect.Value{reflect.Indirect(dst)}
+}
+
+func relationParents(rv reflect.Value) ([]reflect.Value, error) {
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	//nolint:exhaustive // process only struct and slice
+	switch rv.Kind() {
+	case reflect.Struct:
+		if !rv.CanAddr() {
+			return nil, fmt.Errorf("%w: the struct must be passed by pointer", ErrRelationParents)
+		}
+
+		return []reflect.Value{rv}, nil
+
+	case reflect.Slice:
+		values := make([]reflect.Value, 0, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+
+			if item.Kind() == reflect.Pointer {
+				if item.IsNil() {
+					continue
+				}
+
+				item = item.Elem()
+			}
+
+			if item.Kind() != reflect.Struct {
+				return nil, ErrRelationParents
+			}
+
+			values = append(values, item)
+		}
+
+		return values, nil
+
+	default:
+		return nil, ErrRelationParents
+	}
+}
+
+// relationKey returns the comparable form of the key, false for NULL keys.
+func relationKey(rv reflect.Value) (string, bool) {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return "", false
+		}
+
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return "", false
+	}
+
+	return fmt.Sprint(rv.Interface()), true
+}
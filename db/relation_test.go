@@ -0,0 +1,152 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+)
+
+type (
+	relationOrder struct {
+		ID       int64                `db:"id"`
+		Customer string               `db:"customer"`
+		Items    []relationOrderItem  `relation:"order_items,id=order_id"`
+		Pointers []*relationOrderItem `relation:"order_items,id=order_id"`
+	}
+
+	relationOrderItem struct {
+		ID        int64            `db:"id"`
+		OrderID   int64            `db:"order_id"`
+		ProductID *int64           `db:"product_id"`
+		DeletedAt *time.Time       `db:"deleted_at,deleted"`
+		Order     *relationOrder   `relation:"orders,order_id=id"`
+		Product   relationProduct  `relation:"products,product_id=id"`
+		Invalid   relationProduct  `relation:"products"`
+		Unknown   *relationProduct `relation:"products,unknown=id"`
+	}
+
+	relationProduct struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+)
+
+func TestLoadRelation(t *testing.T) {
+	testDB := dbtest.New(t, dbtest.WithSQL(
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT NOT NULL)",
+		"CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		`CREATE TABLE order_items (
+			id INTEGER PRIMARY KEY,
+			order_id INTEGER NOT NULL,
+			product_id INTEGER,
+			deleted_at DATETIME
+		)`,
+		"INSERT INTO orders (id, customer) VALUES (1, 'foo'), (2, 'bar'), (3, 'baz')",
+		"INSERT INTO products (id, name) VALUES (1, 'apple'), (2, 'pear')",
+		`INSERT INTO order_items (id, order_id, product_id, deleted_at) VALUES
+			(1, 1, 1, NULL), (2, 1, 2, NULL), (3, 2, 1, NULL), (4, 2, NULL, CURRENT_TIMESTAMP)`,
+	))
+	ctx := context.Background()
+
+	var orders []relationOrder
+
+	if err := testDB.Select(ctx, &orders, "SELECT id, customer FROM orders ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test one to many", func(t *testing.T) {
+		t.Helper()
+
+		err := db.LoadRelation(ctx, testDB, orders, "Items", db.WithOrderBy("id DESC"))
+
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case len(orders[0].Items) != 2 || orders[0].Items[0].ID != 2 || orders[0].Items[1].ID != 1:
+			t.Errorf("got %+v, want items 2 and 1", orders[0].Items)
+		case len(orders[1].Items) != 1 || orders[1].Items[0].ID != 3:
+			t.Errorf("got %+v, want item 3 without the deleted one", orders[1].Items)
+		case orders[2].Items == nil || len(orders[2].Items) != 0:
+			t.Errorf("got %+v, want the empty slice", orders[2].Items)
+		}
+	})
+
+	t.Run("test one to many pointers with options", func(t *testing.T) {
+		t.Helper()
+
+		err := db.LoadRelation(ctx, testDB, &orders, "Pointers", db.WithDeleted(), db.WithFilter("product_id", nil))
+
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case len(orders[0].Pointers) != 0:
+			t.Errorf("got %d items, want 0", len(orders[0].Pointers))
+		case len(orders[1].Pointers) != 1 || orders[1].Pointers[0].ID != 4:
+			t.Errorf("got %+v, want the deleted item 4", orders[1].Pointers)
+		}
+	})
+
+	t.Run("test many to one and nested", func(t *testing.T) {
+		t.Helper()
+
+		if err := db.LoadRelation(ctx, testDB, orders, "Items.Product"); err != nil {
+			t.Fatal(err)
+		}
+
+		if first, second := orders[0].Items[0].Product.Name, orders[0].Items[1].Product.Name; first != "apple" || second != "pear" {
+			t.Errorf("got %q and %q, want apple and pear", first, second)
+		}
+
+		item := &orders[1].Items[0]
+
+		if err := db.LoadRelation(ctx, testDB, item, "Order"); err != nil {
+			t.Fatal(err)
+		}
+
+		if item.Order == nil || item.Order.Customer != "bar" {
+			t.Errorf("got %+v, want the order of bar", item.Order)
+		}
+	})
+
+	t.Run("test null keys", func(t *testing.T) {
+		t.Helper()
+
+		items := []*relationOrderItem{{ID: 4, OrderID: 2, Product: relationProduct{Name: "stale"}}, nil}
+
+		if err := db.LoadRelation(ctx, testDB, items, "Product"); err != nil {
+			t.Error(err)
+		} else if items[0].Product.Name != "" {
+			t.Errorf("got %+v, want the zero product", items[0].Product)
+		}
+	})
+
+	t.Run("test invalid relations", func(t *testing.T) {
+		t.Helper()
+
+		tests := []struct {
+			name    string
+			parents any
+			path    string
+			opts    []db.ListOption
+			err     error
+		}{
+			{"struct by value", orders[0], "Items", nil, db.ErrRelationParents},
+			{"not a struct", []int{1}, "Items", nil, db.ErrRelationParents},
+			{"unknown field", orders, "Products", nil, db.ErrRelation},
+			{"untagged field", orders, "Customer", nil, db.ErrRelation},
+			{"invalid tag", orders, "Items.Invalid", nil, db.ErrRelation},
+			{"unknown column", orders, "Items.Unknown", nil, db.ErrUnknownColumn},
+			{"unknown order", orders, "Items", []db.ListOption{db.WithOrderBy("foo")}, db.ErrUnknownColumn},
+		}
+
+		for _, test := range tests {
+			if err := db.LoadRelation(ctx, testDB, test.parents, test.path, test.opts...); !errors.Is(err, test.err) {
+				t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+			}
+		}
+	})
+}
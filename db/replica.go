@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	RoundRobin Balancer = iota
+	LeastLatency
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = time.Second
+)
+
+// primaryQueryRegex matches SELECT statements which lock rows, write into tables
+// or call known writing functions.
+var primaryQueryRegex = regexp.MustCompile(`(?i)\bFOR\s+(UPDATE|SHARE|NO\s+KEY\s+UPDATE|KEY\s+SHARE)\b` +
+	`|\bLOCK\s+IN\s+SHARE\s+MODE\b|\bINTO\b` +
+	`|\b(nextval|setval|pg_(try_)?advisory_\w+|get_lock|release_lock|release_all_locks)\s*\(`)
+
+type (
+	// Balancer is the strategy of choosing a replica for read queries.
+	Balancer int
+
+	replica struct {
+		db      *sql.DB
+		healthy atomic.Bool
+		latency atomic.Int64
+	}
+
+	replicaSet struct {
+		replicas []*replica
+		balancer Balancer
+		next     atomic.Uint64
+		done     chan struct{}
+		wg       sync.WaitGroup
+	}
+
+	primaryContextKey struct{}
+)
+
+// WithPrimary returns the context routing read queries to the primary,
+// e.g. to read own writes which are not replicated yet.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+func isPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryContextKey{}).(bool)
+	return forced
+}
+
+// reader returns the connection for the query: a healthy replica for the SELECT statements
+// and the primary for everything else or when the primary is forced by the context.
+// Locking reads and SELECT statements calling writing functions are sent to the primary
+// as well, other writing functions must be routed with WithPrimary.
+func (db *DB) reader(ctx context.Context, query string) *sql.DB {
+	if db.replicas == nil || isPrimaryForced(ctx) || !isReadQuery(query) {
+		return db.DB
+	}
+
+	if replica := db.replicas.pick(); replica != nil {
+		return replica
+	}
+
+	return db.DB
+}
+
+func newReplicaSet(dbs []*sql.DB, balancer Balancer, interval, timeout time.Duration) *replicaSet {
+	rs := &replicaSet{
+		replicas: make([]*replica, len(dbs)),
+		balancer: balancer,
+		done:     make(chan struct{}),
+	}
+
+	for i, item := range dbs {
+		rs.replicas[i] = &replica{db: item}
+		rs.replicas[i].healthy.Store(true)
+	}
+
+	if interval > 0 {
+		rs.wg.Add(1)
+
+		go rs.healthCheck(interval, timeout)
+	}
+
+	return rs
+}
+
+func (rs *replicaSet) pick() *sql.DB {
+	switch rs.balancer {
+	case LeastLatency:
+		var best *replica
+
+		for _, item := range rs.replicas {
+			if item.healthy.Load() && (best == nil || item.latency.Load() < best.latency.Load()) {
+				best = item
+			}
+		}
+
+		if best != nil {
+			return best.db
+		}
+	default:
+		for range rs.replicas {
+			//nolint:gosec // the number of replicas is small
+			item := rs.replicas[int((rs.next.Add(1)-1)%uint64(len(rs.replicas)))]
+
+			if item.healthy.Load() {
+				return item.db
+			}
+		}
+	}
+
+	return nil
+}
+
+func (rs *replicaSet) healthCheck(interval, timeout time.Duration) {
+	defer rs.wg.Done()
+
+	rs.check(timeout)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.done:
+			return
+		case <-ticker.C:
+			rs.check(timeout)
+		}
+	}
+}
+
+func (rs *replicaSet) check(timeout time.Duration) {
+	for _, item := range rs.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		start := time.Now()
+		err := item.db.PingContext(ctx)
+
+		cancel()
+
+		item.healthy.Store(err == nil)
+		item.latency.Store(int64(time.Since(start)))
+	}
+}
+
+func (rs *replicaSet) close() error {
+	close(rs.done)
+	rs.wg.Wait()
+
+	errs := make([]error, 0, len(rs.replicas))
+
+	for _, item := range rs.replicas {
+		errs = append(errs, item.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func isReadQuery(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+
+	return len(query) >= len("SELECT") && strings.EqualFold(query[:len("SELECT")], "SELECT") &&
+		!primaryQueryRegex.MatchString(query)
+}
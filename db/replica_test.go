@@ -0,0 +1,145 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newReplicaFile(t *testing.T, name string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name+".db")
+
+	fileDB, err := db.New(db.WithConfig(&db.Config{Database: path, Driver: db.SQLite}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = fileDB.Close()
+	}()
+
+	if _, err = fileDB.Exec("CREATE TABLE foo (name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = fileDB.Exec("INSERT INTO foo (name) VALUES (?)", name); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReplica(t *testing.T) {
+	primary := newReplicaFile(t, "primary")
+	replica := newReplicaFile(t, "replica")
+
+	ctx := context.Background()
+
+	t.Run("test routing", func(t *testing.T) {
+		t.Helper()
+
+		testDB, err := db.New(
+			db.WithConfig(&db.Config{Database: primary, Driver: db.SQLite}),
+			db.WithConfig(&db.Config{Database: replica, Driver: db.SQLite, Role: db.RoleReplica}),
+			db.WithConfig(&db.Config{Database: replica, Driver: db.SQLite, Role: db.RoleReplica}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		var name string
+
+		if err = testDB.Get(ctx, &name, "SELECT name FROM foo"); err != nil {
+			t.Fatal(err)
+		} else if name != "replica" {
+			t.Errorf("got %q, want read from replica", name)
+		}
+
+		if err = testDB.Get(db.WithPrimary(ctx), &name, "SELECT name FROM foo"); err != nil {
+			t.Fatal(err)
+		} else if name != "primary" {
+			t.Errorf("got %q, want forced read from primary", name)
+		}
+
+		// SQLite has no locking clauses, so the clause is passed in the comment
+		if err = testDB.Get(ctx, &name, "SELECT name FROM foo /* FOR UPDATE */"); err != nil {
+			t.Fatal(err)
+		} else if name != "primary" {
+			t.Errorf("got %q, want locking read from primary", name)
+		}
+
+		if err = testDB.Get(ctx, &name, "UPDATE foo SET name = name RETURNING name"); err != nil {
+			t.Fatal(err)
+		} else if name != "primary" {
+			t.Errorf("got %q, want write to primary", name)
+		}
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			return testDB.Get(tx.Context(), &name, "SELECT name FROM foo")
+		})
+		if err != nil {
+			t.Fatal(err)
+		} else if name != "primary" {
+			t.Errorf("got %q, want read from primary in transaction", name)
+		}
+	})
+
+	t.Run("test unhealthy replica", func(t *testing.T) {
+		t.Helper()
+
+		testDB, err := db.New(
+			db.WithConfig(&db.Config{Database: primary, Driver: db.SQLite}),
+			db.WithConfig(&db.Config{
+				Database: filepath.Join(t.TempDir(), "missing", "replica.db"),
+				Driver:   db.SQLite,
+				Role:     db.RoleReplica,
+			}),
+			db.WithBalancer(db.LeastLatency),
+			db.WithHealthCheck(10*time.Millisecond, time.Second),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			_ = testDB.Close()
+		}()
+
+		var name string
+
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if err = testDB.Get(ctx, &name, "SELECT name FROM foo"); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		} else if name != "primary" {
+			t.Errorf("got %q, want fallback to primary", name)
+		}
+	})
+
+	t.Run("test multiple primary", func(t *testing.T) {
+		t.Helper()
+
+		_, err := db.New(
+			db.WithConfig(&db.Config{Database: primary, Driver: db.SQLite}),
+			db.WithConfig(&db.Config{Database: replica, Driver: db.SQLite, Role: db.RolePrimary}),
+		)
+		if !errors.Is(err, db.ErrMultiplePrimary) {
+			t.Errorf("got error %v, want %v", err, db.ErrMultiplePrimary)
+		}
+	})
+}
@@ -0,0 +1,739 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tagOptionPK      = "pk"
+	tagOptionVersion = "version"
+	tagOptionDeleted = "deleted"
+
+	defaultPrimaryKey = "id"
+)
+
+var (
+	ErrRepositoryType  = errors.New("repository type must be a struct")
+	ErrNoPrimaryKey    = errors.New("repository type has no primary key")
+	ErrUnknownColumn   = errors.New("unknown column")
+	ErrVersionConflict = errors.New("record was changed by another update")
+	ErrNoUpdateColumns = errors.New("no columns to update")
+)
+
+type (
+	// Repository implements CRUD operations for the table mapped to T by the `db` tags:
+	//
+	//	type User struct {
+	//		ID        int64      `db:"id,pk,auto"`
+	//		Name      string     `db:"name"`
+	//		Version   int        `db:"version,version"`
+	//		CreatedAt time.Time  `db:"created_at,auto"`
+	//		DeletedAt *time.Time `db:"deleted_at,deleted"`
+	//	}
+	//
+	// The `pk` option marks the primary key (the `id` column by default), `auto` columns are
+	// generated by the database, the `version` column enables optimistic locking and
+	// the `deleted` column makes Delete mark rows as deleted instead of removing them.
+	// With WithTenantColumn every query is scoped to the tenant of the context.
+	Repository[T any, ID any] struct {
+		q          Querier
+		table      string
+		columns    []*field
+		names      map[string]*field
+		pk         *field
+		version    *field
+		deleted    *field
+		tenant     *field
+		auditTable string
+	}
+
+	repositoryOptions struct {
+		tenantColumn string
+		auditTable   string
+	}
+
+	repositoryOptionFunc func(*repositoryOptions)
+
+	RepositoryOption interface {
+		apply(*repositoryOptions)
+	}
+
+	filter struct {
+		column string
+		value  any
+	}
+
+	order struct {
+		field *field
+		desc  bool
+	}
+
+	listOptions struct {
+		filters []filter
+		where   []string
+		args    []any
+		orderBy []string
+		limit   int
+		offset  int
+		deleted bool
+
+		// scope is the tenant condition, it is kept apart from the raw conditions of options
+		scope     string
+		scopeArgs []any
+
+		// keyring and table encrypt the filters of encrypted columns
+		keyring *Keyring
+		table   string
+	}
+
+	listOptionFunc func(*listOptions)
+
+	ListOption interface {
+		apply(*listOptions)
+	}
+)
+
+func (f listOptionFunc) apply(o *listOptions) {
+	f(o)
+}
+
+func (f repositoryOptionFunc) apply(o *repositoryOptions) {
+	f(o)
+}
+
+// WithTenantColumn scopes the repository by the string column holding the tenant, queries
+// match only rows of the tenant from the context (see WithTenant) and fail with ErrNoTenant
+// without it, created rows get the tenant of the context.
+func WithTenantColumn(column string) RepositoryOption {
+	return repositoryOptionFunc(func(o *repositoryOptions) {
+		o.tenantColumn = column
+	})
+}
+
+// WithFilter keeps rows where the column equals the value, the nil value is compared
+// with IS NULL and slices are compared with IN.
+func WithFilter(column string, value any) ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.filters = append(o.filters, filter{column: column, value: value})
+	})
+}
+
+// WithWhere adds the raw condition with question placeholders, the condition is
+// parenthesized and joined with other conditions by AND.
+func WithWhere(condition string, args ...any) ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.where = append(o.where, condition)
+		o.args = append(o.args, args...)
+	})
+}
+
+// WithOrderBy sorts rows by the columns, the column can be followed by ASC or DESC.
+func WithOrderBy(columns ...string) ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.orderBy = append(o.orderBy, columns...)
+	})
+}
+
+func WithLimit(limit int) ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.limit = limit
+	})
+}
+
+func WithOffset(offset int) ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.offset = offset
+	})
+}
+
+// WithDeleted includes soft deleted rows.
+func WithDeleted() ListOption {
+	return listOptionFunc(func(o *listOptions) {
+		o.deleted = true
+	})
+}
+
+// NewRepository creates the repository of the table, q can be *DB or *Tx.
+func NewRepository[T any, ID any](q Querier, table string, opts ...RepositoryOption) (*Repository[T, ID], error) {
+	rt := reflect.TypeFor[T]()
+	if !isStruct(rt) {
+		return nil, ErrRepositoryType
+	}
+
+	sm := getStructMap(rt)
+	r := &Repository[T, ID]{q: q, table: table, columns: sm.fields, names: sm.names}
+
+	for _, f := range sm.fields {
+		switch {
+		case f.options.Has(tagOptionPK):
+			r.pk = f
+		case f.options.Has(tagOptionVersion):
+			r.version = f
+		case f.options.Has(tagOptionDeleted):
+			r.deleted = f
+		}
+	}
+
+	if r.pk == nil {
+		r.pk = sm.names[defaultPrimaryKey]
+	}
+
+	if r.pk == nil {
+		return nil, ErrNoPrimaryKey
+	}
+
+	o := &repositoryOptions{}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	r.auditTable = o.auditTable
+
+	if o.tenantColumn != "" {
+		r.tenant = sm.names[o.tenantColumn]
+
+		switch {
+		case r.tenant == nil:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, o.tenantColumn)
+		case rt.FieldByIndex(r.tenant.index).Type.Kind() != reflect.String:
+			return nil, fmt.Errorf("%w: %s", ErrTenantColumnType, o.tenantColumn)
+		}
+	}
+
+	return r, nil
+}
+
+// WithQuerier returns the copy of the repository running queries with q,
+// e.g. to use the repository inside the transaction.
+func (r *Repository[T, ID]) WithQuerier(q Querier) *Repository[T, ID] {
+	clone := *r
+	clone.q = q
+
+	return &clone
+}
+
+// Get returns the row by the primary key or sql.ErrNoRows.
+func (r *Repository[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	var (
+		value T
+		buf   strings.Builder
+	)
+
+	buf.WriteString("SELECT ")
+	buf.WriteString(columnNames(r.columns))
+	buf.WriteString(" FROM ")
+	buf.WriteString(r.table)
+	buf.WriteString(" WHERE ")
+
+	condition, args, err := r.scoped(ctx, r.primaryCondition(), argValue(reflect.ValueOf(id)))
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(condition)
+
+	if err = r.q.Get(WithEncryptedTable(ctx, r.table), &value, Rebind(r.q.DriverName(), buf.String()), args...); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// List returns rows matching the filters, soft deleted rows are skipped unless WithDeleted is used.
+func (r *Repository[T, ID]) List(ctx context.Context, opts ...ListOption) ([]T, error) {
+	return r.list(ctx, newListOptions(opts))
+}
+
+func (r *Repository[T, ID]) list(ctx context.Context, o *listOptions) ([]T, error) {
+	if err := r.scopeList(ctx, o); err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString("SELECT ")
+	buf.WriteString(columnNames(r.columns))
+	buf.WriteString(" FROM ")
+	buf.WriteString(r.table)
+
+	args, err := writeWhere(&buf, r.names, r.deleted, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.writeOrder(&buf, o); err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0)
+
+	if err = r.q.Select(WithEncryptedTable(ctx, r.table), &result, Rebind(r.q.DriverName(), buf.String()), args...); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Count returns the number of rows matching the filters, the order and the pagination are ignored.
+func (r *Repository[T, ID]) Count(ctx context.Context, opts ...ListOption) (int64, error) {
+	var (
+		buf   strings.Builder
+		count int64
+	)
+
+	o := newListOptions(opts)
+
+	if err := r.scopeList(ctx, o); err != nil {
+		return 0, err
+	}
+
+	buf.WriteString("SELECT COUNT(*) FROM ")
+	buf.WriteString(r.table)
+
+	args, err := writeWhere(&buf, r.names, r.deleted, o)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = r.q.Get(ctx, &count, Rebind(r.q.DriverName(), buf.String()), args...); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Create inserts the value, the zero version is set to 1 and auto columns are scanned back.
+func (r *Repository[T, ID]) Create(ctx context.Context, value *T) error {
+	if table := r.auditLog(ctx); table != "" {
+		return r.auditCreate(ctx, table, value)
+	}
+
+	rv := reflect.ValueOf(value).Elem()
+
+	if err := r.setTenant(ctx, rv); err != nil {
+		return err
+	}
+
+	if r.version != nil {
+		if version := fieldByIndex(rv, r.version.index); version.IsZero() {
+			setVersion(version, 1)
+		}
+	}
+
+	return Insert(ctx, r.q, r.table, value)
+}
+
+// Update saves all columns of the value or only the given columns. With the version column
+// the row is updated only when its version matches the value, otherwise ErrVersionConflict
+// is returned, on success the version of the value is incremented.
+func (r *Repository[T, ID]) Update(ctx context.Context, value *T, columns ...string) error {
+	if table := r.auditLog(ctx); table != "" {
+		return r.auditUpdate(ctx, table, value, columns)
+	}
+
+	fields, err := r.updateFields(columns)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) == 0 && r.version == nil {
+		return ErrNoUpdateColumns
+	}
+
+	ctx = WithEncryptedTable(ctx, r.table)
+	rv := reflect.ValueOf(value).Elem()
+	args := rowArgs(rv, fields)
+	id := r.pk.arg(valueByIndex(rv, r.pk.index))
+
+	var buf strings.Builder
+
+	buf.WriteString("UPDATE ")
+	buf.WriteString(r.table)
+	buf.WriteString(" SET ")
+
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(f.name)
+		buf.WriteString(" = ?")
+	}
+
+	if r.version != nil {
+		if len(fields) > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(r.version.name)
+		buf.WriteString(" = ")
+		buf.WriteString(r.version.name)
+		buf.WriteString(" + 1")
+	}
+
+	buf.WriteString(" WHERE ")
+
+	condition, conditionArgs, err := r.scoped(ctx, r.primaryCondition(), id)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(condition)
+
+	args = append(args, conditionArgs...)
+
+	if r.version != nil {
+		buf.WriteString(" AND ")
+		buf.WriteString(r.version.name)
+		buf.WriteString(" = ?")
+
+		args = append(args, valueByIndex(rv, r.version.index).Interface())
+	}
+
+	affected, err := r.exec(ctx, buf.String(), args...)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		// MySQL reports zero affected rows for unchanged values, so the row is checked separately
+		exists, err := r.exists(ctx, id)
+
+		switch {
+		case err != nil:
+			return err
+		case !exists:
+			return sql.ErrNoRows
+		case r.version != nil:
+			return ErrVersionConflict
+		}
+	}
+
+	if r.version != nil {
+		version := fieldByIndex(rv, r.version.index)
+		setVersion(version, versionValue(version)+1)
+	}
+
+	return nil
+}
+
+// Delete marks the row as deleted when the type has the deleted column and removes it otherwise.
+func (r *Repository[T, ID]) Delete(ctx context.Context, id ID) error {
+	if table := r.auditLog(ctx); table != "" {
+		return r.auditDelete(ctx, table, id, false)
+	}
+
+	if r.deleted == nil {
+		return r.ForceDelete(ctx, id)
+	}
+
+	condition, args, err := r.scoped(ctx, r.primaryCondition(), argValue(reflect.ValueOf(id)))
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE " + r.table + " SET " + r.deleted.name + " = ? WHERE " + condition
+
+	affected, err := r.exec(ctx, query, append([]any{time.Now()}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ForceDelete removes the row even when the type has the deleted column.
+func (r *Repository[T, ID]) ForceDelete(ctx context.Context, id ID) error {
+	if table := r.auditLog(ctx); table != "" {
+		return r.auditDelete(ctx, table, id, true)
+	}
+
+	condition, args, err := r.scoped(ctx, r.pk.name+" = ?", argValue(reflect.ValueOf(id)))
+	if err != nil {
+		return err
+	}
+
+	affected, err := r.exec(ctx, "DELETE FROM "+r.table+" WHERE "+condition, args...)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func newListOptions(opts []ListOption) *listOptions {
+	o := &listOptions{}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+// primaryCondition matches the row by the primary key skipping soft deleted rows.
+func (r *Repository[T, ID]) primaryCondition() string {
+	if r.deleted == nil {
+		return r.pk.name + " = ?"
+	}
+
+	return r.pk.name + " = ? AND " + r.deleted.name + " IS NULL"
+}
+
+// scoped adds the tenant condition to the condition of the row.
+func (r *Repository[T, ID]) scoped(ctx context.Context, condition string, args ...any) (string, []any, error) {
+	tenant, tenantArgs, err := r.tenantCondition(ctx)
+	if err != nil || tenant == "" {
+		return condition, args, err
+	}
+
+	return condition + " AND " + tenant, append(args, tenantArgs...), nil
+}
+
+// scopeList scopes the list options to the table and the tenant of the context.
+func (r *Repository[T, ID]) scopeList(ctx context.Context, o *listOptions) error {
+	o.keyring, o.table = settingsOf(r.q).keyring, r.table
+
+	tenant, args, err := r.tenantCondition(ctx)
+	if err != nil || tenant == "" {
+		return err
+	}
+
+	o.scope, o.scopeArgs = tenant, args
+
+	return nil
+}
+
+func (r *Repository[T, ID]) updateFields(columns []string) ([]*field, error) {
+	if len(columns) == 0 {
+		fields := make([]*field, 0, len(r.columns))
+
+		for _, f := range r.columns {
+			if f != r.pk && f != r.version && f != r.deleted && f != r.tenant && !f.options.Has(tagOptionAuto) {
+				fields = append(fields, f)
+			}
+		}
+
+		return fields, nil
+	}
+
+	fields := make([]*field, len(columns))
+
+	for i, column := range columns {
+		f, ok := r.names[column]
+		if !ok || f == r.pk || f == r.version || f == r.tenant {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+		}
+
+		fields[i] = f
+	}
+
+	return fields, nil
+}
+
+// writeWhere writes the conditions of the options validating filter columns by the names,
+// rows with the deleted column set are skipped unless WithDeleted is used and rows
+// of other tenants are always skipped.
+func writeWhere(buf *strings.Builder, names map[string]*field, deleted *field, o *listOptions) ([]any, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if o.scope != "" {
+		conditions = append(conditions, o.scope)
+		args = append(args, o.scopeArgs...)
+	}
+
+	if deleted != nil && !o.deleted {
+		conditions = append(conditions, deleted.name+" IS NULL")
+	}
+
+	for _, item := range o.filters {
+		f, ok := names[item.column]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, item.column)
+		}
+
+		if f.options.Has(tagOptionEncrypted) {
+			value, err := encryptedFilter(o.keyring, o.table, f, item.value)
+			if err != nil {
+				return nil, err
+			}
+
+			item.value = value
+		}
+
+		condition, values := filterCondition(item)
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+
+	// raw conditions are parenthesized, so OR never escapes the other conditions
+	for _, condition := range o.where {
+		conditions = append(conditions, "("+condition+")")
+	}
+
+	args = append(args, o.args...)
+
+	if len(conditions) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(conditions, " AND "))
+	}
+
+	return args, nil
+}
+
+func (r *Repository[T, ID]) writeOrder(buf *strings.Builder, o *listOptions) error {
+	orders, err := parseOrder(r.names, o.orderBy)
+	if err != nil {
+		return err
+	}
+
+	// SQL Server paginates only sorted rows
+	if len(orders) == 0 && r.q.DriverName() == SQLServer && (o.limit > 0 || o.offset > 0) {
+		orders = append(orders, order{field: r.pk})
+	}
+
+	for i, item := range orders {
+		if i == 0 {
+			buf.WriteString(" ORDER BY ")
+		} else {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(item.field.name)
+
+		if item.desc {
+			buf.WriteString(" DESC")
+		}
+	}
+
+	writePagination(buf, r.q.DriverName(), o.limit, o.offset)
+
+	return nil
+}
+
+// parseOrder resolves the sort columns, the column can be followed by ASC or DESC.
+func parseOrder(names map[string]*field, orderBy []string) ([]order, error) {
+	orders := make([]order, len(orderBy))
+
+	for i, item := range orderBy {
+		column, direction, _ := strings.Cut(strings.TrimSpace(item), " ")
+		direction = strings.ToUpper(strings.TrimSpace(direction))
+
+		f, ok := names[column]
+		if !ok || (direction != "" && direction != "ASC" && direction != "DESC") {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, item)
+		}
+
+		orders[i] = order{field: f, desc: direction == "DESC"}
+	}
+
+	return orders, nil
+}
+
+func (r *Repository[T, ID]) exists(ctx context.Context, id any) (bool, error) {
+	var count int
+
+	condition, args, err := r.scoped(ctx, r.primaryCondition(), id)
+	if err != nil {
+		return false, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + r.table + " WHERE " + condition
+
+	if err = r.q.Get(ctx, &count, Rebind(r.q.DriverName(), query), args...); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *Repository[T, ID]) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	result, err := r.q.ExecContext(ctx, Rebind(r.q.DriverName(), query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func filterCondition(item filter) (string, []any) {
+	if item.value == nil {
+		return item.column + " IS NULL", nil
+	}
+
+	values, err := expandValue(item.value)
+
+	switch {
+	case err != nil:
+		// the empty list never matches
+		return "1 = 0", nil
+	case len(values) == 1:
+		return item.column + " = ?", values
+	default:
+		return item.column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", values
+	}
+}
+
+func writePagination(buf *strings.Builder, drv DriverName, limit, offset int) {
+	if drv == SQLServer {
+		if limit > 0 || offset > 0 {
+			buf.WriteString(" OFFSET " + strconv.Itoa(offset) + " ROWS")
+		}
+
+		if limit > 0 {
+			buf.WriteString(" FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY")
+		}
+
+		return
+	}
+
+	switch {
+	case limit > 0:
+		buf.WriteString(" LIMIT " + strconv.Itoa(limit))
+	case offset > 0 && drv == SQLite:
+		// SQLite and MySQL do not accept OFFSET without LIMIT
+		buf.WriteString(" LIMIT -1")
+	case offset > 0 && drv == MySQL:
+		buf.WriteString(" LIMIT 18446744073709551615")
+	}
+
+	if offset > 0 {
+		buf.WriteString(" OFFSET " + strconv.Itoa(offset))
+	}
+}
+
+func versionValue(rv reflect.Value) int64 {
+	if rv.CanUint() {
+		return int64(rv.Uint()) //nolint:gosec // versions are small
+	}
+
+	return rv.Int()
+}
+
+func setVersion(rv reflect.Value, version int64) {
+	if rv.CanUint() {
+		rv.SetUint(uint64(version)) //nolint:gosec // versions are positive
+		return
+	}
+
+	rv.SetInt(version)
+}
@@ -0,0 +1,266 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type (
+	repositoryUser struct {
+		ID        int64      `db:"id,pk,auto"`
+		Name      string     `db:"name"`
+		Status    string     `db:"status"`
+		Version   int        `db:"version,version"`
+		CreatedAt time.Time  `db:"created_at,auto"`
+		DeletedAt *time.Time `db:"deleted_at,deleted"`
+	}
+
+	repositoryTag struct {
+		Name  string `db:"name,pk"`
+		Count int    `db:"count"`
+	}
+)
+
+func newRepositoryDB(t *testing.T, opts ...db.Option) *db.DB {
+	t.Helper()
+
+	testDB, err := db.New(append([]db.Option{db.WithConfigDSN("sqlite://:memory:")}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err = testDB.Exec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			status TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP
+		);
+		CREATE TABLE tags (name TEXT PRIMARY KEY, count INTEGER NOT NULL);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = testDB.Close()
+	})
+
+	return testDB
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	testDB := newRepositoryDB(t)
+
+	users, err := db.NewRepository[repositoryUser, int64](testDB, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		user := &repositoryUser{Name: name, Status: "active"}
+
+		if err = users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		} else if user.ID == 0 || user.Version != 1 || user.CreatedAt.IsZero() {
+			t.Fatalf("auto fields are not set: %+v", user)
+		}
+	}
+
+	t.Run("test get", func(t *testing.T) {
+		t.Helper()
+
+		user, err := users.Get(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		} else if user.Name != "bar" {
+			t.Errorf("got %q, want bar", user.Name)
+		}
+
+		if _, err = users.Get(ctx, 100); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+
+	t.Run("test list", func(t *testing.T) {
+		t.Helper()
+
+		list, err := users.List(ctx,
+			db.WithFilter("status", "active"),
+			db.WithFilter("id", []int64{1, 2, 3}),
+			db.WithOrderBy("name DESC"),
+			db.WithLimit(2),
+			db.WithOffset(1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(list) != 2 || list[0].Name != "baz" || list[1].Name != "bar" {
+			t.Errorf("got %+v, want [baz bar]", list)
+		}
+
+		list, err = users.List(ctx, db.WithFilter("id", []int64{1, 2, 3}), db.WithOrderBy("id"), db.WithOffset(2))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(list) != 1 || list[0].ID != 3 {
+			t.Errorf("got %+v, want the third user", list)
+		}
+
+		count, err := users.Count(ctx, db.WithWhere("name LIKE ?", "ba%"))
+		if err != nil {
+			t.Fatal(err)
+		} else if count != 2 {
+			t.Errorf("got %d, want 2", count)
+		}
+
+		if _, err = users.List(ctx, db.WithOrderBy("name; DROP TABLE users")); !errors.Is(err, db.ErrUnknownColumn) {
+			t.Errorf("got error %v, want %v", err, db.ErrUnknownColumn)
+		}
+
+		if _, err = users.List(ctx, db.WithFilter("unknown", 1)); !errors.Is(err, db.ErrUnknownColumn) {
+			t.Errorf("got error %v, want %v", err, db.ErrUnknownColumn)
+		}
+	})
+
+	t.Run("test update with optimistic locking", func(t *testing.T) {
+		t.Helper()
+
+		user, err := users.Get(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stale := *user
+
+		user.Name = "updated"
+		user.Status = "blocked"
+
+		if err = users.Update(ctx, user, "name"); err != nil {
+			t.Fatal(err)
+		} else if user.Version != 2 {
+			t.Errorf("got version %d, want 2", user.Version)
+		}
+
+		saved, err := users.Get(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		} else if saved.Name != "updated" || saved.Status != "active" {
+			t.Errorf("partial update changed other columns: %+v", saved)
+		}
+
+		stale.Status = "blocked"
+
+		if err = users.Update(ctx, &stale); !errors.Is(err, db.ErrVersionConflict) {
+			t.Errorf("got error %v, want %v", err, db.ErrVersionConflict)
+		}
+
+		if err = users.Update(ctx, user); err != nil {
+			t.Fatal(err)
+		} else if user.Version != 3 {
+			t.Errorf("got version %d, want 3", user.Version)
+		}
+
+		if err = users.Update(ctx, &repositoryUser{ID: 100}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+
+	t.Run("test soft delete", func(t *testing.T) {
+		t.Helper()
+
+		if err := users.Delete(ctx, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := users.Get(ctx, 3); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+
+		if err := users.Delete(ctx, 3); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+
+		if count, err := users.Count(ctx); err != nil || count != 2 {
+			t.Errorf("got %d (%v), want 2", count, err)
+		}
+
+		if count, err := users.Count(ctx, db.WithDeleted()); err != nil || count != 3 {
+			t.Errorf("got %d (%v), want 3", count, err)
+		}
+
+		count, err := users.Count(ctx, db.WithWhere("name = ? OR name = ?", "updated", "baz"))
+		if err != nil || count != 1 {
+			t.Errorf("got %d (%v), want 1", count, err)
+		}
+
+		if err := users.ForceDelete(ctx, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		if count, err := users.Count(ctx, db.WithDeleted()); err != nil || count != 2 {
+			t.Errorf("got %d (%v), want 2", count, err)
+		}
+	})
+
+	t.Run("test transaction", func(t *testing.T) {
+		t.Helper()
+
+		tags, err := db.NewRepository[repositoryTag, string](testDB, "tags")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errRollback := errors.New("rollback")
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			txTags := tags.WithQuerier(tx)
+
+			if err := txTags.Create(ctx, &repositoryTag{Name: "foo", Count: 1}); err != nil {
+				return err
+			}
+
+			if err := txTags.Update(ctx, &repositoryTag{Name: "foo", Count: 2}); err != nil {
+				return err
+			}
+
+			if tag, err := txTags.Get(ctx, "foo"); err != nil || tag.Count != 2 {
+				t.Errorf("got %+v (%v), want updated tag", tag, err)
+			}
+
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatal(err)
+		}
+
+		if _, err = tags.Get(ctx, "foo"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want rolled back tag", err)
+		}
+
+		if err = tags.Delete(ctx, "foo"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+
+	t.Run("test invalid type", func(t *testing.T) {
+		t.Helper()
+
+		if _, err := db.NewRepository[int, int](testDB, "users"); !errors.Is(err, db.ErrRepositoryType) {
+			t.Errorf("got error %v, want %v", err, db.ErrRepositoryType)
+		}
+
+		if _, err := db.NewRepository[struct{ Name string }, int](testDB, "users"); !errors.Is(err, db.ErrNoPrimaryKey) {
+			t.Errorf("got error %v, want %v", err, db.ErrNoPrimaryKey)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 10 * time.Millisecond
+	defaultRetryMaxDelay  = time.Second
+	maxRetryShift         = 10
+
+	sqlServerDeadlock    = 1205
+	mysqlDeadlock        = 1213
+	mysqlLockWaitTimeout = 1205
+	mysqlSchemaChanged   = 1615
+)
+
+var mysqlErrorRegex = regexp.MustCompile(`\bError (\d+)(?: \([0-9A-Z]{5}\))?:`)
+
+type (
+	txOptions struct {
+		attempts     int
+		backoff      func(attempt int) time.Duration
+		retryable    func(drv DriverName, err error) bool
+		tenantSchema func(tenant string) string
+	}
+
+	txOptionFunc func(*txOptions)
+
+	TxOption interface {
+		apply(*txOptions)
+	}
+)
+
+func (f txOptionFunc) apply(o *txOptions) {
+	f(o)
+}
+
+// WithRetry sets the maximum number of attempts of the transaction.
+func WithRetry(attempts int) TxOption {
+	return txOptionFunc(func(o *txOptions) {
+		o.attempts = attempts
+	})
+}
+
+// WithRetryBackoff sets the delay before the next attempt, by default
+// the delay grows exponentially from 10ms up to 1s with a random jitter.
+func WithRetryBackoff(backoff func(attempt int) time.Duration) TxOption {
+	return txOptionFunc(func(o *txOptions) {
+		o.backoff = backoff
+	})
+}
+
+// WithRetryClassifier replaces the default classification of retryable errors.
+func WithRetryClassifier(retryable func(err error) bool) TxOption {
+	return txOptionFunc(func(o *txOptions) {
+		o.retryable = func(_ DriverName, err error) bool {
+			return retryable(err)
+		}
+	})
+}
+
+func newTxOptions(opts []TxOption) *txOptions {
+	o := &txOptions{
+		attempts:  1,
+		backoff:   exponentialBackoff,
+		retryable: IsRetryable,
+	}
+
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+
+	return o
+}
+
+// IsRetryable reports whether the transaction failed with an error which is expected
+// to succeed on retry: serialization failures and deadlocks (SQLSTATE 40001 and 40P01,
+// MySQL errors 1213 and 1205, SQL Server error 1205) or the busy database for SQLite.
+func IsRetryable(drv DriverName, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	//nolint:exhaustive // other drivers report SQLSTATE codes
+	switch drv {
+	case SQLite:
+		message := err.Error()
+
+		return strings.Contains(message, "database is locked") || strings.Contains(message, "database table is locked")
+	case MySQL:
+		number, ok := mysqlErrorNumber(err)
+
+		return ok && (number == mysqlDeadlock || number == mysqlLockWaitTimeout)
+	case SQLServer:
+		var numberErr interface{ SQLErrorNumber() int32 }
+
+		return errors.As(err, &numberErr) && numberErr.SQLErrorNumber() == sqlServerDeadlock
+	default:
+		var stateErr interface{ SQLState() string }
+
+		if !errors.As(err, &stateErr) {
+			return false
+		}
+
+		switch stateErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// mysqlErrorNumber returns the number of the MySQL error. The error of the driver has no methods,
+// so the number is taken from its Number field or from the message `Error 1213 (40001): ...`.
+func mysqlErrorNumber(err error) (uint16, bool) {
+	for queue := []error{err}; len(queue) > 0; queue = queue[1:] {
+		switch current := queue[0].(type) {
+		case interface{ Unwrap() error }:
+			if next := current.Unwrap(); next != nil {
+				queue = append(queue, next)
+			}
+		case interface{ Unwrap() []error }:
+			queue = append(queue, current.Unwrap()...)
+		}
+
+		rv := reflect.Indirect(reflect.ValueOf(queue[0]))
+		if rv.Kind() != reflect.Struct {
+			continue
+		}
+
+		if number := rv.FieldByName("Number"); number.IsValid() && number.Kind() == reflect.Uint16 {
+			return uint16(number.Uint()), true
+		}
+	}
+
+	match := mysqlErrorRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+
+	number, parseErr := strconv.ParseUint(match[1], 10, 16)
+
+	return uint16(number), parseErr == nil
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	delay := defaultRetryBaseDelay << min(attempt-1, maxRetryShift)
+	if delay > defaultRetryMaxDelay {
+		delay = defaultRetryMaxDelay
+	}
+
+	//nolint:gosec // jitter does not need a secure random
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,160 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type stateError string
+
+func (e stateError) Error() string {
+	return "sql state " + string(e)
+}
+
+func (e stateError) SQLState() string {
+	return string(e)
+}
+
+type numberError int32
+
+func (e numberError) Error() string {
+	return "mssql error"
+}
+
+func (e numberError) SQLErrorNumber() int32 {
+	return int32(e)
+}
+
+// mysqlError has the shape of the MySQL driver error.
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string {
+	return e.Message
+}
+
+func TestRetry(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	noDelay := db.WithRetryBackoff(func(int) time.Duration { return 0 })
+	retryable := db.WithRetryClassifier(func(err error) bool {
+		return db.IsRetryable(db.Postgres, err)
+	})
+
+	t.Run("test retry serialization failure", func(t *testing.T) {
+		t.Helper()
+
+		var attempts []int
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			attempts = append(attempts, tx.Attempt())
+
+			if _, err := tx.Exec("INSERT INTO foo (id) VALUES (1)"); err != nil {
+				return err
+			}
+
+			if tx.Attempt() < 3 {
+				return stateError("40001")
+			}
+
+			return nil
+		}, db.WithRetry(5), noDelay, retryable)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(attempts) != 3 || attempts[2] != 3 {
+			t.Errorf("got attempts %v, want [1 2 3]", attempts)
+		}
+	})
+
+	t.Run("test retry limit", func(t *testing.T) {
+		t.Helper()
+
+		var count int
+
+		err = testDB.TransactionalTx(ctx, nil, func(*db.Tx) error {
+			count++
+			return stateError("40P01")
+		}, db.WithRetry(2), noDelay, retryable)
+
+		if !errors.Is(err, stateError("40P01")) {
+			t.Errorf("got error %v, want deadlock error", err)
+		} else if count != 2 {
+			t.Errorf("got %d attempts, want 2", count)
+		}
+	})
+
+	t.Run("test not retryable error", func(t *testing.T) {
+		t.Helper()
+
+		var count int
+
+		err = testDB.TransactionalTx(ctx, nil, func(*db.Tx) error {
+			count++
+			return stateError("23505")
+		}, db.WithRetry(3), noDelay, retryable)
+
+		if err == nil || count != 1 {
+			t.Errorf("got %d attempts with error %v, want 1", count, err)
+		}
+	})
+
+	t.Run("test context cancel during backoff", func(t *testing.T) {
+		t.Helper()
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+
+		err = testDB.TransactionalTx(cancelCtx, nil, func(*db.Tx) error {
+			cancel()
+			return errors.New("database is locked")
+		}, db.WithRetry(3))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("test classification", func(t *testing.T) {
+		t.Helper()
+
+		switch {
+		case !db.IsRetryable(db.SQLite, errors.New("database is locked")):
+			t.Error("sqlite busy error is not retryable")
+		case db.IsRetryable(db.SQLite, stateError("40001")):
+			t.Error("sqlite state error is retryable")
+		case !db.IsRetryable(db.PGX, stateError("40P01")):
+			t.Error("deadlock error is not retryable")
+		case db.IsRetryable(db.PGX, nil):
+			t.Error("nil error is retryable")
+		case !db.IsRetryable(db.MySQL, errors.New("Error 1213 (40001): Deadlock found")): //nolint:stylecheck // mysql format
+			t.Error("mysql deadlock error is not retryable")
+		case db.IsRetryable(db.MySQL, errors.New("Error 12050 (HY000): Unknown error")): //nolint:stylecheck // mysql format
+			t.Error("mysql error 12050 is retryable")
+		case !db.IsRetryable(db.MySQL, fmt.Errorf("commit: %w", &mysqlError{Number: 1205, Message: "Lock wait timeout"})):
+			t.Error("mysql lock wait timeout is not retryable")
+		case !db.IsRetryable(db.SQLServer, numberError(1205)):
+			t.Error("sql server deadlock error is not retryable")
+		case db.IsRetryable(db.SQLServer, numberError(2627)):
+			t.Error("sql server unique violation is retryable")
+		}
+	})
+}
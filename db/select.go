@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/kamilov/go-kit/utils/structure"
 )
@@ -15,10 +17,36 @@ const tagName = "db"
 
 var (
 	ErrPointerType        = errors.New("a pointer was not expected")
+	ErrUnmappedColumn     = errors.New("column is not mapped to a struct field")
+	ErrMapType            = errors.New("map key must be a string")
+	ErrResultType         = errors.New("result destination must be a pointer to a slice")
+	ErrNoResultSet        = errors.New("query returned fewer result sets than destinations")
 	camelCaseToSnakeRegex = regexp.MustCompile(`([^A-Z_])([A-Z])`)
+	scannerType           = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType              = reflect.TypeOf(time.Time{})
+)
+
+type (
+	queryer interface {
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	}
+
+	rowScanner func(rows *sql.Rows, rv reflect.Value) error
 )
 
 func (db *DB) Select(ctx context.Context, data any, query string, args ...any) error {
+	return db.cached(ctx, data, query, args, func() error {
+		return selectContext(ctx, db, db.settings, data, query, args...)
+	})
+}
+
+func (db *DB) Get(ctx context.Context, data any, query string, args ...any) error {
+	return db.cached(ctx, data, query, args, func() error {
+		return getContext(ctx, db, db.settings, data, query, args...)
+	})
+}
+
+func selectContext(ctx context.Context, q queryer, s settings, data any, query string, args ...any) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -30,10 +58,13 @@ func (db *DB) Select(ctx context.Context, data any, query string, args ...any) e
 	rp := reflect.ValueOf(data)
 	rv := reflect.Indirect(rp)
 
-	//nolint:exhaustive // process only struct and slice
+	//nolint:exhaustive // process only struct, map and slice
 	switch rv.Kind() {
-	case reflect.Struct:
-		rows, err := db.QueryContext(ctx, query, args...)
+	case reflect.Struct, reflect.Map:
+		return getContext(ctx, q, s, data, query, args...)
+
+	case reflect.Slice:
+		rows, err := q.QueryContext(ctx, query, args...)
 		if err != nil {
 			return err
 		}
@@ -42,86 +73,246 @@ func (db *DB) Select(ctx context.Context, data any, query string, args ...any) e
 			_ = rows.Close()
 		}()
 
-		if !rows.Next() {
+		count, err := scanSlice(ctx, rows, s, rp)
+		if err != nil {
+			return err
+		}
+
+		s.observeRows(ctx, query, count)
+	default:
+		return ErrPointerType
+	}
+
+	return nil
+}
+
+func getContext(ctx context.Context, q queryer, s settings, data any, query string, args ...any) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := structure.ValidatePointer(data); err != nil {
+		return err
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		return sql.ErrNoRows
+	}
+
+	rv := reflect.ValueOf(data).Elem()
+
+	scan, err := newRowScanner(ctx, rows, rv.Type(), s)
+	if err != nil {
+		return err
+	}
+
+	if err = scan(rows, rv); err != nil {
+		return err
+	}
+
+	s.observeRows(ctx, query, 1)
+
+	return nil
+}
+
+// SelectResults scans the result sets of the query, e.g. a batch of statements or a stored
+// procedure, into the pointers to slices in order. ErrNoResultSet is returned when the query
+// returns fewer result sets than destinations, the driver must support multiple result sets.
+func SelectResults(ctx context.Context, q Querier, dest []any, query string, args ...any) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	for _, data := range dest {
+		rv := reflect.ValueOf(data)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+			return fmt.Errorf("%w: %T", ErrResultType, data)
+		}
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	s := settingsOf(q)
+
+	var total int64
+
+	for i, data := range dest {
+		if i > 0 && !rows.NextResultSet() {
 			if err = rows.Err(); err != nil {
 				return err
 			}
 
-			return sql.ErrNoRows
+			return fmt.Errorf("%w: %d", ErrNoResultSet, i+1)
 		}
 
-		return scanStruct(rows, rv)
-
-	case reflect.Slice:
-		rows, err := db.QueryContext(ctx, query, args...)
+		count, err := scanSlice(ctx, rows, s, reflect.ValueOf(data))
 		if err != nil {
 			return err
 		}
 
-		defer func() {
-			_ = rows.Close()
-		}()
+		total += count
+	}
 
-		for rows.Next() {
-			val := reflect.New(rv.Type().Elem())
+	s.observeRows(ctx, query, total)
 
-			if rv.Type().Elem().Kind() == reflect.Struct {
-				if err = scanStruct(rows, val); err != nil {
-					return err
-				}
-			} else {
-				if err = rows.Scan(val.Interface()); err != nil {
-					return err
-				}
-			}
+	return nil
+}
 
-			rv = reflect.Append(rv, val.Elem())
-		}
+// scanSlice appends the rows of the current result set to the slice pointed by rp.
+func scanSlice(ctx context.Context, rows *sql.Rows, s settings, rp reflect.Value) (int64, error) {
+	rv := rp.Elem()
+
+	scan, err := newRowScanner(ctx, rows, rv.Type().Elem(), s)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
 
-		if rp.Elem().CanSet() {
-			rp.Elem().Set(rv)
+	for rows.Next() {
+		val := reflect.New(rv.Type().Elem()).Elem()
+
+		if err = scan(rows, val); err != nil {
+			return 0, err
 		}
-	default:
-		return ErrPointerType
+
+		rv = reflect.Append(rv, val)
+		count++
 	}
 
-	return nil
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rp.Elem().Set(rv)
+
+	return count, nil
 }
 
-func scanStruct(rows *sql.Rows, rv reflect.Value) error {
-	rv = reflect.Indirect(rv)
-	fieldNameIndex := make(map[string]int)
+// newRowScanner compiles the mapping between the result columns and the target type once,
+// so the returned scanner can be reused for every row of the result set. In strict mode
+// columns without a matching struct field are reported as an error.
+func newRowScanner(ctx context.Context, rows *sql.Rows, rt reflect.Type, s settings) (rowScanner, error) {
+	if rt.Kind() == reflect.Map {
+		return newMapScanner(rows, rt)
+	}
+
+	if !isStruct(rt) {
+		return func(rows *sql.Rows, rv reflect.Value) error {
+			return rows.Scan(scanTarget(rv))
+		}, nil
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < rv.Type().NumField(); i++ {
-		var name string
+	sm := getStructMap(rt)
+	mapping := make([]*field, len(columns))
 
-		field := rv.Type().Field(i)
-		tag := field.Tag.Get(tagName)
+	for i, col := range columns {
+		f, ok := sm.names[col]
+		if !ok {
+			if s.strict {
+				return nil, fmt.Errorf("%w: %s", ErrUnmappedColumn, col)
+			}
 
-		switch tag {
-		case "-":
 			continue
-		case "":
-			name = fieldMap(field.Name)
-		default:
-			name = tag
 		}
 
-		fieldNameIndex[name] = i
+		mapping[i] = f
 	}
 
-	columns, _ := rows.Columns()
-	fields := make([]any, len(columns))
+	return func(rows *sql.Rows, rv reflect.Value) error {
+		fields := make([]any, len(mapping))
 
-	for i, col := range columns {
-		if index, ok := fieldNameIndex[col]; ok {
-			fields[i] = rv.Field(index).Addr().Interface()
-		} else {
-			fields[i] = &sql.NullString{}
+		for i, f := range mapping {
+			if f == nil {
+				fields[i] = new(any)
+			} else {
+				fields[i] = f.scanTarget(ctx, s, fieldByIndex(rv, f.index))
+			}
 		}
+
+		return rows.Scan(fields...)
+	}, nil
+}
+
+// newMapScanner scans rows into maps keyed by the column names, text columns scanned
+// into `any` values as bytes are stored as strings.
+func newMapScanner(rows *sql.Rows, rt reflect.Type) (rowScanner, error) {
+	if rt.Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("%w: %s", ErrMapType, rt)
 	}
 
-	return rows.Scan(fields...)
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]reflect.Value, len(columns))
+
+	for i, col := range columns {
+		keys[i] = reflect.ValueOf(col).Convert(rt.Key())
+	}
+
+	return func(rows *sql.Rows, rv reflect.Value) error {
+		values := make([]reflect.Value, len(columns))
+		fields := make([]any, len(columns))
+
+		for i := range values {
+			values[i] = reflect.New(rt.Elem()).Elem()
+			fields[i] = scanTarget(values[i])
+		}
+
+		if err := rows.Scan(fields...); err != nil {
+			return err
+		}
+
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rt, len(columns)))
+		}
+
+		for i, key := range keys {
+			value := values[i]
+
+			if value.Kind() == reflect.Interface {
+				if data, ok := value.Interface().([]byte); ok {
+					value = reflect.ValueOf(string(data))
+				}
+			}
+
+			rv.SetMapIndex(key, value)
+		}
+
+		return nil
+	}, nil
+}
+
+func isStruct(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Struct && rt != timeType && !reflect.PointerTo(rt).Implements(scannerType) &&
+		!hasConverter(rt)
 }
 
 func fieldMap(name string) string {
@@ -101,6 +101,40 @@ func TestSelect(t *testing.T) {
 		}
 	})
 
+	t.Run("test select maps", func(t *testing.T) {
+		t.Helper()
+
+		var rows []map[string]any
+
+		err = testDB.Select(ctx, &rows, "SELECT id, 'foo' || id AS name FROM foo WHERE id <= 2 ORDER BY id")
+		switch {
+		case err != nil:
+			t.Error(err)
+		case len(rows) != 2:
+			t.Errorf("got %d rows, want 2", len(rows))
+		case rows[1]["id"] != int64(2) || rows[1]["name"] != "foo2":
+			t.Errorf("got %v, want id 2 and name foo2", rows[1])
+		}
+	})
+
+	t.Run("test select map", func(t *testing.T) {
+		t.Helper()
+
+		var row map[string]string
+
+		err = testDB.Select(ctx, &row, "SELECT id FROM foo WHERE id = 3")
+		if err != nil {
+			t.Error(err)
+		} else if row["id"] != "3" {
+			t.Errorf("got %q, want %q", row["id"], "3")
+		}
+
+		err = testDB.Select(ctx, &map[int]any{}, "SELECT id FROM foo WHERE id = 3")
+		if !errors.Is(err, db.ErrMapType) {
+			t.Errorf("got %v, want %v", err, db.ErrMapType)
+		}
+	})
+
 	t.Run("test select struct not found", func(t *testing.T) {
 		t.Helper()
 
@@ -114,3 +148,46 @@ func TestSelect(t *testing.T) {
 		}
 	})
 }
+
+func TestSelectResults(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	ctx := context.Background()
+
+	t.Run("test single result set", func(t *testing.T) {
+		t.Helper()
+
+		var ids []int
+
+		err := db.SelectResults(ctx, testDB, []any{&ids}, "SELECT 1 UNION ALL SELECT 2")
+		if err != nil {
+			t.Error(err)
+		} else if len(ids) != 2 {
+			t.Errorf("got %d ids, want 2", len(ids))
+		}
+	})
+
+	t.Run("test missing result set", func(t *testing.T) {
+		t.Helper()
+
+		var ids, names []string
+
+		err := db.SelectResults(ctx, testDB, []any{&ids, &names}, "SELECT 1")
+		if !errors.Is(err, db.ErrNoResultSet) {
+			t.Errorf("got %v, want %v", err, db.ErrNoResultSet)
+		}
+	})
+
+	t.Run("test invalid destination", func(t *testing.T) {
+		t.Helper()
+
+		var id int
+
+		err := db.SelectResults(ctx, testDB, []any{&id}, "SELECT 1")
+		if !errors.Is(err, db.ErrResultType) {
+			t.Errorf("got %v, want %v", err, db.ErrResultType)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var schemaQueryRegex = regexp.MustCompile(`(?i)(^|;)\s*(CREATE|ALTER|DROP|RENAME|TRUNCATE)\b`)
+
+type (
+	// stmtCache keeps the prepared statements of the connection pool, the evicted statement
+	// is closed when the last query using it returns. Queries without arguments are not cached,
+	// e.g. DDL and scripts of several statements which can not be prepared.
+	stmtCache struct {
+		mutex sync.Mutex
+		pool  *sql.DB
+		items *lru[*cachedStmt]
+	}
+
+	cachedStmt struct {
+		stmt    *sql.Stmt
+		refs    int
+		evicted bool
+	}
+)
+
+// ResetStatements closes the cached prepared statements. It is called after DDL statements
+// made by the DB and by committed transactions, schema changes made by other processes
+// are detected by the errors of the statements only.
+func (db *DB) ResetStatements() {
+	for _, cache := range db.stmts {
+		cache.reset()
+	}
+}
+
+// isSchemaQuery reports whether the query changes the schema, so the prepared statements
+// may return outdated columns.
+func isSchemaQuery(query string) bool {
+	return schemaQueryRegex.MatchString(query)
+}
+
+func newStmtCaches(size int, pools ...*sql.DB) map[*sql.DB]*stmtCache {
+	caches := make(map[*sql.DB]*stmtCache, len(pools))
+
+	for _, pool := range pools {
+		c := &stmtCache{pool: pool}
+		c.items = newLRU[*cachedStmt](size, func(item *cachedStmt) {
+			item.evicted = true
+
+			if item.refs == 0 {
+				_ = item.stmt.Close()
+			}
+		})
+
+		caches[pool] = c
+	}
+
+	return caches
+}
+
+func (db *DB) execContext(ctx context.Context, pool *sql.DB, query string, args ...any) (sql.Result, error) {
+	cache, ok := db.stmts[pool]
+	if !ok || len(args) == 0 {
+		return pool.ExecContext(ctx, query, args...)
+	}
+
+	var result sql.Result
+
+	err := cache.run(ctx, db.driver, query, func(stmt *sql.Stmt) (err error) {
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+
+	return result, err
+}
+
+func (db *DB) queryContext(ctx context.Context, pool *sql.DB, query string, args ...any) (*sql.Rows, error) {
+	cache, ok := db.stmts[pool]
+	if !ok || len(args) == 0 {
+		return pool.QueryContext(ctx, query, args...)
+	}
+
+	var rows *sql.Rows
+
+	err := cache.run(ctx, db.driver, query, func(stmt *sql.Stmt) (err error) {
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// queryRowContext uses the cached statement as well, but the schema error of the row
+// is returned by Scan, so the statement is prepared again only on the next query.
+func (db *DB) queryRowContext(ctx context.Context, pool *sql.DB, query string, args ...any) *sql.Row {
+	cache, ok := db.stmts[pool]
+	if !ok || len(args) == 0 {
+		return pool.QueryRowContext(ctx, query, args...)
+	}
+
+	item, err := cache.acquire(ctx, query)
+	if err != nil {
+		return pool.QueryRowContext(ctx, query, args...)
+	}
+
+	defer cache.release(item)
+
+	return item.stmt.QueryRowContext(ctx, args...)
+}
+
+// run calls fn with the cached statement, the statement failed with the schema error
+// is dropped and fn is retried once with the statement prepared again.
+func (c *stmtCache) run(ctx context.Context, drv DriverName, query string, fn func(stmt *sql.Stmt) error) error {
+	for attempt := 1; ; attempt++ {
+		item, err := c.acquire(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		err = fn(item.stmt)
+
+		c.release(item)
+
+		if err == nil || attempt > 1 || !isSchemaError(drv, err) {
+			return err
+		}
+
+		c.mutex.Lock()
+		if current, ok := c.items.get(query); ok && current == item {
+			c.items.remove(query)
+		}
+		c.mutex.Unlock()
+	}
+}
+
+func (c *stmtCache) acquire(ctx context.Context, query string) (*cachedStmt, error) {
+	c.mutex.Lock()
+
+	if item, ok := c.items.get(query); ok {
+		item.refs++
+		c.mutex.Unlock()
+
+		return item, nil
+	}
+
+	c.mutex.Unlock()
+
+	// the statement is prepared without the lock, the concurrent one prepared first wins
+	stmt, err := c.pool.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if item, ok := c.items.get(query); ok {
+		_ = stmt.Close()
+		item.refs++
+
+		return item, nil
+	}
+
+	item := &cachedStmt{stmt: stmt, refs: 1}
+	c.items.add(query, item)
+
+	return item, nil
+}
+
+func (c *stmtCache) release(item *cachedStmt) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item.refs--
+
+	if item.evicted && item.refs == 0 {
+		_ = item.stmt.Close()
+	}
+}
+
+func (c *stmtCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items.clear()
+}
+
+// isSchemaError reports whether the prepared statement is outdated by the schema change:
+// the changed result type of the cached plan on Postgres (SQLSTATE 0A000), MySQL error 1615
+// or the changed schema on SQLite.
+func isSchemaError(drv DriverName, err error) bool {
+	message := err.Error()
+
+	//nolint:exhaustive // other drivers report SQLSTATE codes
+	switch drv {
+	case SQLite:
+		return strings.Contains(message, "schema has changed")
+	case MySQL:
+		number, ok := mysqlErrorNumber(err)
+
+		return ok && number == mysqlSchemaChanged
+	default:
+		var stateErr interface{ SQLState() string }
+
+		return (errors.As(err, &stateErr) && stateErr.SQLState() == "0A000") ||
+			strings.Contains(message, "cached plan must not change result type")
+	}
+}
@@ -0,0 +1,186 @@
+package db_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/loghole/dbhook"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type prepareCounter struct {
+	count atomic.Int32
+}
+
+func (c *prepareCounter) Before(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	if input.Caller == dbhook.CallerStmt {
+		c.count.Add(1)
+	}
+
+	return ctx, nil
+}
+
+func TestStatementCache(t *testing.T) {
+	ctx := context.Background()
+	counter := &prepareCounter{}
+
+	testDB, err := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+		db.WithStatementCache(2),
+		db.WithHookBefore(counter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		_ = testDB.Close()
+	})
+
+	_, err = testDB.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL); CREATE TABLE tags (name TEXT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counter.count.Load() != 0 {
+		t.Fatalf("got %d prepares of the script, want 0", counter.count.Load())
+	}
+
+	t.Run("test reuse", func(t *testing.T) {
+		t.Helper()
+
+		for i := range 3 {
+			if _, err := testDB.ExecContext(ctx, "INSERT INTO items (id, name) VALUES (?, ?)", i+1, "item"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var names []string
+
+		for range 3 {
+			names = nil
+
+			if err := testDB.Select(ctx, &names, "SELECT name FROM items WHERE id > ?", 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var name string
+
+		if err := testDB.QueryRowContext(ctx, "SELECT name FROM items WHERE id = ?", 1).Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := counter.count.Swap(0); got != 3 || len(names) != 2 || name != "item" {
+			t.Errorf("got %d prepares and %v, want 3 prepares and 2 names", got, names)
+		}
+	})
+
+	t.Run("test eviction", func(t *testing.T) {
+		t.Helper()
+
+		queries := []string{
+			"SELECT COUNT(*) FROM items WHERE id = ?",
+			"SELECT COUNT(*) FROM items WHERE id < ?",
+			"SELECT COUNT(*) FROM items WHERE id > ?",
+		}
+
+		for range 2 {
+			for _, query := range queries {
+				var count int
+
+				if err := testDB.Get(ctx, &count, query, 2); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		if got := counter.count.Swap(0); got != 6 {
+			t.Errorf("got %d prepares, want 6", got)
+		}
+	})
+
+	t.Run("test schema change", func(t *testing.T) {
+		t.Helper()
+
+		var before, after []map[string]any
+
+		query := "SELECT * FROM items WHERE id = ?"
+
+		if err := testDB.Select(ctx, &before, query, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := testDB.ExecContext(ctx, "ALTER TABLE items ADD COLUMN price INTEGER NOT NULL DEFAULT 10")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := testDB.Select(ctx, &after, query, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(before[0]) != 2 || len(after[0]) != 3 {
+			t.Errorf("got %v before and %v after, want the new column", before, after)
+		}
+
+		testDB.ResetStatements()
+		counter.count.Store(0)
+
+		if err := testDB.Select(ctx, &after, query, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := counter.count.Load(); got != 1 {
+			t.Errorf("got %d prepares after reset, want 1", got)
+		}
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			_, err := tx.ExecContext(ctx, "ALTER TABLE items ADD COLUMN stock INTEGER NOT NULL DEFAULT 0")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		after = nil
+
+		if err = testDB.Select(ctx, &after, query, 1); err != nil || len(after[0]) != 4 {
+			t.Errorf("got %v and %v, want the column added in the transaction", after, err)
+		}
+	})
+
+	t.Run("test schema change without context", func(t *testing.T) {
+		t.Helper()
+
+		var after []map[string]any
+
+		query := "SELECT * FROM items WHERE id = ?"
+
+		if _, err := testDB.Exec("ALTER TABLE items ADD COLUMN discount INTEGER NOT NULL DEFAULT 0"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := testDB.Select(ctx, &after, query, 1); err != nil || len(after[0]) != 5 {
+			t.Errorf("got %v and %v, want the column added by Exec", after, err)
+		}
+
+		err := testDB.Transactional(func(tx *db.Tx) error {
+			_, err := tx.Exec("ALTER TABLE items ADD COLUMN weight INTEGER NOT NULL DEFAULT 0")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		after = nil
+
+		if err = testDB.Select(ctx, &after, query, 1); err != nil || len(after[0]) != 6 {
+			t.Errorf("got %v and %v, want the column added by Tx.Exec", after, err)
+		}
+	})
+}
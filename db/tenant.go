@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/loghole/dbhook"
+)
+
+var (
+	ErrNoTenant          = errors.New("tenant is not set in the context")
+	ErrTenantMismatch    = errors.New("value belongs to another tenant")
+	ErrTenantUnsupported = errors.New("tenant schemas are not supported by the driver")
+	ErrTenantColumnType  = errors.New("tenant column must be a string")
+)
+
+type (
+	tenantHook struct{}
+
+	tenantContextKey      struct{}
+	crossTenantContextKey struct{}
+)
+
+// WithTenant returns the context carrying the tenant, it scopes repositories with the tenant
+// column, transactions with WithTenantSchema and passes WithTenantGuard.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// WithoutTenant returns the context of queries intentionally crossing tenants, e.g. migrations,
+// background jobs and admin reports. Repositories skip the tenant filter and the guard passes.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, crossTenantContextKey{}, true)
+}
+
+func isCrossTenant(ctx context.Context) bool {
+	cross, _ := ctx.Value(crossTenantContextKey{}).(bool)
+	return cross
+}
+
+// WithTenantGuard fails every query made with the context lacking the tenant with ErrNoTenant,
+// so the forgotten tenant never reads or changes the data of all tenants.
+func WithTenantGuard() Option {
+	return WithHookBefore(tenantHook{})
+}
+
+// WithTenantSchema isolates the transaction in the schema of the tenant from the context
+// by setting the search path of the transaction, the nil schema uses the tenant as the schema
+// name. The transaction fails with ErrNoTenant when ctx carries no tenant. Only Postgres
+// supports schemas, the path is reset on commit, so pooled connections never leak it.
+func WithTenantSchema(schema func(tenant string) string) TxOption {
+	return txOptionFunc(func(o *txOptions) {
+		if schema == nil {
+			schema = func(tenant string) string { return tenant }
+		}
+
+		o.tenantSchema = schema
+	})
+}
+
+func (tenantHook) Before(ctx context.Context, input *dbhook.HookInput) (context.Context, error) {
+	//nolint:exhaustive // transaction control and preparation do not touch the data
+	switch input.Caller {
+	case dbhook.CallerExec, dbhook.CallerQuery, dbhook.CallerStmtExec, dbhook.CallerStmtQuery:
+	default:
+		return ctx, nil
+	}
+
+	if _, ok := TenantFromContext(ctx); !ok && !isCrossTenant(ctx) {
+		return ctx, ErrNoTenant
+	}
+
+	return ctx, nil
+}
+
+// setSearchPath switches the transaction to the schema of the tenant.
+func (tx *Tx) setSearchPath(ctx context.Context, schema func(tenant string) string) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	if tx.driver != Postgres && tx.driver != PGX {
+		return fmt.Errorf("%w: %s", ErrTenantUnsupported, tx.driver)
+	}
+
+	_, err := tx.ExecContext(ctx, "SET LOCAL search_path TO "+quoteIdentifier(schema(tenant)))
+
+	return err
+}
+
+// tenantCondition matches rows of the tenant from ctx when the repository has the tenant column.
+func (r *Repository[T, ID]) tenantCondition(ctx context.Context) (string, []any, error) {
+	if r.tenant == nil || isCrossTenant(ctx) {
+		return "", nil, nil
+	}
+
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrNoTenant, r.table)
+	}
+
+	return r.tenant.name + " = ?", []any{tenant}, nil
+}
+
+// setTenant fills the empty tenant column of the value, the value of another tenant
+// is rejected with ErrTenantMismatch.
+func (r *Repository[T, ID]) setTenant(ctx context.Context, rv reflect.Value) error {
+	if r.tenant == nil || isCrossTenant(ctx) {
+		return nil
+	}
+
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoTenant, r.table)
+	}
+
+	value := fieldByIndex(rv, r.tenant.index)
+	current := reflect.ValueOf(tenant).Convert(value.Type())
+
+	switch {
+	case value.IsZero():
+		value.Set(current)
+	case !value.Equal(current):
+		return fmt.Errorf("%w: %v", ErrTenantMismatch, value.Interface())
+	}
+
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,172 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	"github.com/kamilov/go-kit/db/dbtest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type tenantProject struct {
+	ID     int64  `db:"id,pk,auto"`
+	Tenant string `db:"tenant_id"`
+	Name   string `db:"name"`
+}
+
+const tenantSchema = "CREATE TABLE projects (id INTEGER PRIMARY KEY AUTOINCREMENT, tenant_id TEXT NOT NULL, name TEXT NOT NULL)"
+
+func TestTenantRepository(t *testing.T) {
+	testDB := dbtest.New(t, dbtest.WithSQL(tenantSchema))
+	acme := db.WithTenant(context.Background(), "acme")
+	globex := db.WithTenant(context.Background(), "globex")
+
+	projects, err := db.NewRepository[tenantProject, int64](testDB, "projects", db.WithTenantColumn("tenant_id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rocket := &tenantProject{Name: "rocket"}
+
+	if err = projects.Create(acme, rocket); err != nil || rocket.Tenant != "acme" {
+		t.Fatalf("got tenant %q and %v, want acme", rocket.Tenant, err)
+	}
+
+	if err = projects.Create(globex, &tenantProject{Name: "doomsday"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test scoped queries", func(t *testing.T) {
+		t.Helper()
+
+		items, err := projects.List(acme)
+		if err != nil || len(items) != 1 || items[0].Name != "rocket" {
+			t.Errorf("got %+v and %v, want the rocket", items, err)
+		}
+
+		if count, err := projects.Count(globex); err != nil || count != 1 {
+			t.Errorf("got %d and %v, want 1", count, err)
+		}
+
+		items, err = projects.List(acme, db.WithWhere("name = ? OR name = ?", "rocket", "doomsday"))
+		if err != nil || len(items) != 1 || items[0].Name != "rocket" {
+			t.Errorf("got %+v and %v, want the rocket", items, err)
+		}
+
+		if _, err = projects.Get(globex, rocket.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+		}
+
+		rocket.Name = "stolen"
+
+		if err = projects.Update(globex, rocket); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+		}
+
+		if err = projects.Delete(globex, rocket.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+		}
+
+		if err = projects.Update(acme, rocket, "tenant_id"); !errors.Is(err, db.ErrUnknownColumn) {
+			t.Errorf("got %v, want %v", err, db.ErrUnknownColumn)
+		}
+
+		if err = projects.Update(acme, rocket); err != nil {
+			t.Error(err)
+		}
+
+		if count, err := projects.Count(db.WithoutTenant(context.Background())); err != nil || count != 2 {
+			t.Errorf("got %d and %v, want 2", count, err)
+		}
+	})
+
+	t.Run("test missing tenant", func(t *testing.T) {
+		t.Helper()
+
+		ctx := context.Background()
+
+		if _, err := projects.List(ctx); !errors.Is(err, db.ErrNoTenant) {
+			t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+		}
+
+		if _, err := projects.Get(ctx, rocket.ID); !errors.Is(err, db.ErrNoTenant) {
+			t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+		}
+
+		if err := projects.Create(ctx, &tenantProject{Name: "orphan"}); !errors.Is(err, db.ErrNoTenant) {
+			t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+		}
+	})
+
+	t.Run("test tenant mismatch", func(t *testing.T) {
+		t.Helper()
+
+		err := projects.Create(acme, &tenantProject{Tenant: "globex", Name: "spy"})
+		if !errors.Is(err, db.ErrTenantMismatch) {
+			t.Errorf("got %v, want %v", err, db.ErrTenantMismatch)
+		}
+	})
+
+	t.Run("test invalid column", func(t *testing.T) {
+		t.Helper()
+
+		_, err := db.NewRepository[tenantProject, int64](testDB, "projects", db.WithTenantColumn("owner"))
+		if !errors.Is(err, db.ErrUnknownColumn) {
+			t.Errorf("got %v, want %v", err, db.ErrUnknownColumn)
+		}
+
+		_, err = db.NewRepository[tenantProject, int64](testDB, "projects", db.WithTenantColumn("id"))
+		if !errors.Is(err, db.ErrTenantColumnType) {
+			t.Errorf("got %v, want %v", err, db.ErrTenantColumnType)
+		}
+	})
+}
+
+func TestTenantGuard(t *testing.T) {
+	testDB := dbtest.New(t, dbtest.WithSQL(tenantSchema), dbtest.WithDBOptions(db.WithTenantGuard()))
+	ctx := context.Background()
+
+	var count int
+
+	if err := testDB.Get(ctx, &count, "SELECT COUNT(*) FROM projects"); !errors.Is(err, db.ErrNoTenant) {
+		t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+	}
+
+	if _, err := testDB.ExecContext(ctx, "DELETE FROM projects"); !errors.Is(err, db.ErrNoTenant) {
+		t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+	}
+
+	err := testDB.TransactionalTx(db.WithTenant(ctx, "acme"), nil, func(tx *db.Tx) error {
+		return tx.Get(tx.Context(), &count, "SELECT COUNT(*) FROM projects")
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err = testDB.Get(db.WithoutTenant(ctx), &count, "SELECT COUNT(*) FROM projects"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTenantSchema(t *testing.T) {
+	testDB := dbtest.New(t, dbtest.WithSQL(tenantSchema))
+	ctx := context.Background()
+
+	err := testDB.TransactionalTx(ctx, nil, func(*db.Tx) error {
+		t.Error("transaction must not run without the tenant")
+		return nil
+	}, db.WithTenantSchema(nil))
+	if !errors.Is(err, db.ErrNoTenant) {
+		t.Errorf("got %v, want %v", err, db.ErrNoTenant)
+	}
+
+	err = testDB.TransactionalTx(db.WithTenant(ctx, "acme"), nil, func(*db.Tx) error {
+		return nil
+	}, db.WithTenantSchema(func(tenant string) string { return "tenant_" + tenant }))
+	if !errors.Is(err, db.ErrTenantUnsupported) {
+		t.Errorf("got %v, want %v", err, db.ErrTenantUnsupported)
+	}
+}
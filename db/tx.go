@@ -1,7 +1,199 @@
 package db
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
 
-type Tx struct {
-	*sql.Tx
+type (
+	Tx struct {
+		*sql.Tx
+		settings
+		owner   *sql.DB
+		ctx     context.Context
+		depth   int
+		attempt int
+		// resetStatements drops the statements cached by the DB after the committed schema change
+		resetStatements func()
+		schemaChanged   bool
+		// done unbinds the committed or rolled back transaction from its context
+		done atomic.Bool
+	}
+
+	txContextKey struct{}
+)
+
+var (
+	ErrSavepointUnsupported = errors.New("savepoints are not supported by the driver")
+	ErrNestedTxOptions      = errors.New("nested transaction can not change options")
+)
+
+func newTx(ctx context.Context, db *DB, sqlTx *sql.Tx) *Tx {
+	tx := &Tx{Tx: sqlTx, settings: db.settings, owner: db.DB}
+	tx.ctx = context.WithValue(ctx, txContextKey{}, tx)
+
+	if db.stmts != nil {
+		tx.resetStatements = db.ResetStatements
+	}
+
+	return tx
+}
+
+// TxFromContext returns the transaction carried by ctx or nil, the committed
+// or rolled back transaction is not returned.
+func TxFromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(txContextKey{}).(*Tx)
+	if tx == nil || tx.done.Load() {
+		return nil
+	}
+
+	return tx
+}
+
+// Context returns the context carrying the transaction, queries of the DB and nested
+// TransactionalTx calls made with this context run inside the transaction until it is
+// committed or rolled back, then they run outside of it.
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+// Attempt returns the number of the current attempt of TransactionalTx starting from 1.
+func (tx *Tx) Attempt() int {
+	return max(tx.attempt, 1)
+}
+
+// Exec runs ExecContext with the background context, so the schema change resets
+// the statement cache on commit like the other queries of the transaction.
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// Query runs QueryContext with the background context.
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow runs QueryRowContext with the background context.
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := tx.Tx.ExecContext(ctx, query, tx.bindEncrypted(ctx, args)...)
+	if err == nil {
+		tx.observeResult(ctx, query, result)
+
+		if tx.resetStatements != nil && isSchemaQuery(query) {
+			tx.schemaChanged = true
+		}
+	}
+
+	return result, err
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, query, tx.bindEncrypted(ctx, args)...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, query, tx.bindEncrypted(ctx, args)...)
+}
+
+// Commit commits the transaction and drops the cached statements after the schema change.
+func (tx *Tx) Commit() error {
+	tx.done.Store(true)
+
+	err := tx.Tx.Commit()
+	if err == nil && tx.schemaChanged {
+		tx.resetStatements()
+	}
+
+	return err
+}
+
+func (tx *Tx) Rollback() error {
+	tx.done.Store(true)
+
+	return tx.Tx.Rollback()
+}
+
+func (tx *Tx) Select(ctx context.Context, data any, query string, args ...any) error {
+	return selectContext(ctx, tx, tx.settings, data, query, args...)
+}
+
+func (tx *Tx) Get(ctx context.Context, data any, query string, args ...any) error {
+	return getContext(ctx, tx, tx.settings, data, query, args...)
+}
+
+func (tx *Tx) SelectNamed(ctx context.Context, data any, query string, arg any) error {
+	query, args, err := BindNamed(tx.driver, query, arg)
+	if err != nil {
+		return err
+	}
+
+	return tx.Select(ctx, data, query, args...)
+}
+
+func (tx *Tx) GetNamed(ctx context.Context, data any, query string, arg any) error {
+	query, args, err := BindNamed(tx.driver, query, arg)
+	if err != nil {
+		return err
+	}
+
+	return tx.Get(ctx, data, query, args...)
+}
+
+func (tx *Tx) ExecNamed(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return execNamed(ctx, tx, query, arg)
+}
+
+// savepoint runs fn inside a savepoint of the transaction, the savepoint
+// is rolled back when fn returns an error or panics.
+func (tx *Tx) savepoint(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	tx.depth++
+	defer func() {
+		tx.depth--
+	}()
+
+	create, rollback, release, err := savepointQueries(tx.driver, "sp_"+strconv.Itoa(tx.depth))
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, create); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = tx.ExecContext(ctx, rollback)
+			panic(p)
+		} else if err != nil {
+			if _, err2 := tx.ExecContext(ctx, rollback); err2 != nil {
+				err = errors.Join(err, err2)
+			}
+		} else if release != "" {
+			_, err = tx.ExecContext(ctx, release)
+		}
+	}()
+
+	err = fn(tx)
+
+	return err
+}
+
+func savepointQueries(drv DriverName, name string) (string, string, string, error) {
+	//nolint:exhaustive // other drivers do not support savepoints
+	switch drv {
+	case PGX, Postgres, SQLite, MySQL:
+		return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name, "RELEASE SAVEPOINT " + name, nil
+	case SQLServer:
+		// SQL Server releases savepoints with the transaction
+		return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, "", nil
+	default:
+		return "", "", "", ErrSavepointUnsupported
+	}
 }
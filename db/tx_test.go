@@ -0,0 +1,170 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/kamilov/go-kit/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestNestedTransactional(t *testing.T) {
+	testDB, _ := db.New(
+		db.WithConfigDSN("sqlite://:memory:"),
+	)
+
+	testDB.SetMaxOpenConns(1)
+
+	_, err := testDB.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	count := func(ctx context.Context) int {
+		var result int
+
+		if err := testDB.Get(ctx, &result, "SELECT COUNT(*) FROM foo"); err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	t.Run("test inner rollback keeps outer changes", func(t *testing.T) {
+		t.Helper()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			if db.TxFromContext(tx.Context()) != tx {
+				t.Error("transaction is not carried by context")
+			}
+
+			if _, err := testDB.ExecContext(tx.Context(), "INSERT INTO foo (id) VALUES (1)"); err != nil {
+				return err
+			}
+
+			err := testDB.TransactionalTx(tx.Context(), nil, func(inner *db.Tx) error {
+				if inner != tx {
+					t.Error("nested transaction is not reused")
+				}
+
+				if _, err := inner.Exec("INSERT INTO foo (id) VALUES (2)"); err != nil {
+					return err
+				}
+
+				return errors.New("inner error")
+			})
+			if err == nil {
+				t.Error("nested transactional did not return an error")
+			}
+
+			err = testDB.TransactionalTx(tx.Context(), nil, func(inner *db.Tx) error {
+				_, err := inner.Exec("INSERT INTO foo (id) VALUES (3)")
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if got := count(tx.Context()); got != 2 {
+				t.Errorf("got %d rows inside transaction, want 2", got)
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := count(ctx); got != 2 {
+			t.Errorf("got %d rows, want 2", got)
+		}
+	})
+
+	t.Run("test inner panic rolls back savepoint", func(t *testing.T) {
+		t.Helper()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			func() {
+				defer func() {
+					_ = recover()
+				}()
+
+				_ = testDB.TransactionalTx(tx.Context(), nil, func(inner *db.Tx) error {
+					_, _ = inner.Exec("INSERT INTO foo (id) VALUES (4)")
+
+					panic("test error")
+				})
+			}()
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := count(ctx); got != 2 {
+			t.Errorf("got %d rows, want 2", got)
+		}
+	})
+
+	t.Run("test outer rollback discards inner changes", func(t *testing.T) {
+		t.Helper()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			err := testDB.TransactionalTx(tx.Context(), nil, func(inner *db.Tx) error {
+				return db.Insert(tx.Context(), testDB, "foo", struct{ ID int }{5})
+			})
+			if err != nil {
+				return err
+			}
+
+			return errors.New("outer error")
+		})
+		if err == nil {
+			t.Fatal("transactional did not return an error")
+		}
+
+		if got := count(ctx); got != 2 {
+			t.Errorf("got %d rows, want 2", got)
+		}
+	})
+
+	t.Run("test nested options", func(t *testing.T) {
+		t.Helper()
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			return testDB.TransactionalTx(tx.Context(), &sql.TxOptions{ReadOnly: true}, func(*db.Tx) error {
+				return nil
+			})
+		})
+		if !errors.Is(err, db.ErrNestedTxOptions) {
+			t.Errorf("got error %v, want %v", err, db.ErrNestedTxOptions)
+		}
+	})
+
+	t.Run("test finished transaction leaves context", func(t *testing.T) {
+		t.Helper()
+
+		var txCtx context.Context
+
+		err = testDB.TransactionalTx(ctx, nil, func(tx *db.Tx) error {
+			txCtx = tx.Context()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tx := db.TxFromContext(txCtx); tx != nil {
+			t.Error("got the committed transaction")
+		}
+
+		if _, err = testDB.ExecContext(txCtx, "INSERT INTO foo (id) VALUES (6)"); err != nil {
+			t.Error(err)
+		}
+	})
+}
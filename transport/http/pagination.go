@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	HeaderNextCursor = "X-Next-Cursor"
+
+	cursorParam = "cursor"
+)
+
+type (
+	// PageRequest is embedded into the endpoint input to decode the `cursor` and `limit` query params.
+	PageRequest struct {
+		Cursor string `query:"cursor"`
+		Limit  int    `query:"limit"`
+	}
+
+	// PageResponse is the page of items, the cursor of the next page is sent in the body,
+	// in the X-Next-Cursor header and as the next link in the Link header.
+	PageResponse[T any] struct {
+		Items      []T    `json:"items"`
+		NextCursor string `json:"next_cursor,omitempty"`
+
+		next string
+	}
+)
+
+// NewPageResponse creates the page response, the next link is the URL of the current
+// request with the cursor param replaced by the next cursor.
+func NewPageResponse[T any](ctx context.Context, items []T, nextCursor string) *PageResponse[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+
+	response := &PageResponse[T]{Items: items, NextCursor: nextCursor}
+
+	if r, ok := ctx.Value(contextRequestKey).(*http.Request); ok && nextCursor != "" {
+		response.next = nextPageURL(r, nextCursor)
+	}
+
+	return response
+}
+
+// PageLimit returns the limit bounded by the maximum, the zero limit is replaced by the maximum.
+func (p PageRequest) PageLimit(maximum int) int {
+	if p.Limit <= 0 || p.Limit > maximum {
+		return maximum
+	}
+
+	return p.Limit
+}
+
+func (p *PageResponse[T]) Header() http.Header {
+	h := http.Header{}
+
+	if p.NextCursor != "" {
+		h.Set(HeaderNextCursor, p.NextCursor)
+	}
+
+	if p.next != "" {
+		h.Set("Link", "<"+p.next+`>; rel="next"`)
+	}
+
+	return h
+}
+
+func nextPageURL(r *http.Request, cursor string) string {
+	next := *r.URL
+
+	query := next.Query()
+	query.Set(cursorParam, cursor)
+	next.RawQuery = query.Encode()
+
+	if next.Host == "" && r.Host != "" {
+		next.Host = r.Host
+		next.Scheme = "http"
+
+		if r.TLS != nil {
+			next.Scheme = "https"
+		}
+	}
+
+	return next.String()
+}
@@ -0,0 +1,56 @@
+package http_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamilov/go-kit/transport/http"
+)
+
+func TestPageResponse(t *testing.T) {
+	t.Run("test next page headers", func(t *testing.T) {
+		t.Helper()
+
+		r := httptest.NewRequest("GET", "/users?cursor=old&limit=10&status=active", nil)
+		r.TLS = &tls.ConnectionState{}
+
+		response := http.NewPageResponse(http.WithContextRequest(context.Background(), r), []int{1, 2}, "next")
+		header := response.Header()
+
+		if got := header.Get(http.HeaderNextCursor); got != "next" {
+			t.Errorf("got cursor %q, want next", got)
+		}
+
+		if got, want := header.Get("Link"), `<https://example.com/users?cursor=next&limit=10&status=active>; rel="next"`; got != want {
+			t.Errorf("got link %q, want %q", got, want)
+		}
+	})
+
+	t.Run("test last page", func(t *testing.T) {
+		t.Helper()
+
+		r := httptest.NewRequest("GET", "/users", nil)
+
+		response := http.NewPageResponse[int](http.WithContextRequest(context.Background(), r), nil, "")
+
+		if len(response.Header()) != 0 {
+			t.Errorf("got headers %v for the last page", response.Header())
+		}
+
+		if response.Items == nil {
+			t.Error("items must be encoded as the empty list")
+		}
+	})
+
+	t.Run("test page limit", func(t *testing.T) {
+		t.Helper()
+
+		for _, test := range []struct{ limit, want int }{{0, 50}, {10, 10}, {100, 50}} {
+			if got := (http.PageRequest{Limit: test.limit}).PageLimit(50); got != test.want {
+				t.Errorf("got limit %d, want %d", got, test.want)
+			}
+		}
+	})
+}
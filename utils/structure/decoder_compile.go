@@ -4,6 +4,7 @@ import (
 	"encoding"
 	"reflect"
 	"strconv"
+	"time"
 	"unsafe"
 )
 
@@ -24,6 +25,8 @@ const (
 var (
 	//nolint:gochecknoglobals // used for data type caching
 	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	//nolint:gochecknoglobals // used for data type caching
+	durationType = reflect.TypeOf(time.Duration(0))
 )
 
 func noopDecoder(_ reflect.Value, _ Data) error { return nil }
@@ -127,6 +130,21 @@ func decodeFloat[T float32 | float64](set setFunc[T], key string, bits int) deco
 	}
 }
 
+func decodeDuration(set setFunc[time.Duration], key string) decoder {
+	return func(rv reflect.Value, data Data) error {
+		if value := data.Get(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+
+			set(rv, d)
+		}
+
+		return nil
+	}
+}
+
 func decodeBool(set setFunc[bool], key string) decoder {
 	return func(rv reflect.Value, data Data) error {
 		if value := data.Get(key); value != "" {
@@ -174,6 +192,11 @@ func compileDecoders(srt reflect.Type, tagName string, isPointer bool) (decoder,
 			continue
 		}
 
+		if fieldType == durationType {
+			decoders = append(decoders, decodeDuration(set[time.Duration](fieldType, i, fieldIsPointer), tagValue))
+			continue
+		}
+
 		//nolint:exhaustive // we process only certain types
 		switch fieldKind {
 		case reflect.Struct:
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/kamilov/go-kit/utils/structure"
@@ -47,14 +48,16 @@ func TestDecoder(t *testing.T) {
 		Float64              float64        `field:"number"`
 		Bool                 bool           `field:"bool"`
 		Bytes                []byte         `field:"string"`
+		Duration             time.Duration  `field:"duration"`
 		Child                child
 		ChildPointer         *child
 	}
 
 	testMap := Map{
-		"string": "string",
-		"number": "1",
-		"bool":   "TRUE",
+		"string":   "string",
+		"number":   "1",
+		"bool":     "TRUE",
+		"duration": "1m30s",
 	}
 
 	str := "string"
@@ -78,6 +81,7 @@ func TestDecoder(t *testing.T) {
 		Float64:              1,
 		Bool:                 true,
 		Bytes:                []byte("string"),
+		Duration:             time.Minute + 30*time.Second,
 		Child: child{
 			String: "string",
 		},